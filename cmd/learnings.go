@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thisguymartin/ai-forge/internal/learnings"
+)
+
+var (
+	learningsFor       string
+	learningsOlderThan int
+	learningsMinCount  int
+)
+
+var learningsCmd = &cobra.Command{
+	Use:   "learnings",
+	Short: "List, edit and prune the learnings collected across runs",
+	Long: `Lists the learnings MOCHI has collected in .mochi/learnings.json: lessons the
+worker reported with a "LEARNING:" line and issues reviewers sent back. The
+most relevant ones are added to every worker prompt (see --learnings-limit).
+
+With --for, shows only the learnings a task with that description would get.`,
+	Example: `  mochi learnings
+  mochi learnings --for "Fix the mobile navbar"
+  mochi learnings edit 3 "Run 'make generate' before 'go test'"
+  mochi learnings prune 4 7
+  mochi learnings prune --older-than 30`,
+	Args: cobra.NoArgs,
+	RunE: listLearnings,
+}
+
+var learningsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the learnings collected across runs",
+	Args:  cobra.NoArgs,
+	RunE:  listLearnings,
+}
+
+func listLearnings(cmd *cobra.Command, args []string) error {
+	store, err := openLearnings()
+	if err != nil {
+		return err
+	}
+	entries := store.Entries()
+	if learningsFor != "" {
+		entries = store.Relevant(learningsFor, cfg.LearningsLimit)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No learnings.")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("  #%-4d ×%-3d %-9s %s\n", e.ID, e.Count, e.Source, e.Text)
+		if len(e.Tasks) > 0 {
+			fmt.Printf("  %-20s last seen %s in %s\n", "",
+				e.LastSeen.Format("2006-01-02"), strings.Join(e.Tasks, ", "))
+		}
+	}
+	return nil
+}
+
+var learningsEditCmd = &cobra.Command{
+	Use:   "edit <id> [text]",
+	Short: "Replace the text of a learning",
+	Long: `Replaces the text of the learning with the given ID. Without text, the
+learning is opened in $EDITOR.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid learning id %q", args[0])
+		}
+		store, err := openLearnings()
+		if err != nil {
+			return err
+		}
+		text := strings.Join(args[1:], " ")
+		if text == "" {
+			if text, err = editLearning(store, id); err != nil {
+				return err
+			}
+		}
+		if err := store.Edit(id, text); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Updated learning #%d.\n", id)
+		return nil
+	},
+}
+
+var learningsPruneCmd = &cobra.Command{
+	Use:   "prune [id...]",
+	Short: "Remove learnings by ID or by age",
+	Long: `Removes the learnings with the given IDs. With --older-than, also removes
+learnings not seen for that many days, unless they were seen at least
+--min-count times.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && learningsOlderThan <= 0 {
+			return fmt.Errorf("specify learning IDs or --older-than")
+		}
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid learning id %q", arg)
+			}
+			ids[i] = id
+		}
+		store, err := openLearnings()
+		if err != nil {
+			return err
+		}
+		removed := store.Remove(ids...)
+		if learningsOlderThan > 0 {
+			cutoff := time.Now().AddDate(0, 0, -learningsOlderThan)
+			removed += store.PruneStale(cutoff, learningsMinCount)
+		}
+		if removed == 0 {
+			fmt.Println("Nothing to prune.")
+			return nil
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Pruned %d learning(s).\n", removed)
+		return nil
+	},
+}
+
+func openLearnings() (*learnings.Store, error) {
+	root, err := repoRoot()
+	if err != nil {
+		return nil, err
+	}
+	return learnings.Open(filepath.Join(root, learnings.File))
+}
+
+// editLearning opens the current text of learning id in $EDITOR and returns
+// the edited text.
+func editLearning(store *learnings.Store, id int) (string, error) {
+	current := ""
+	found := false
+	for _, e := range store.Entries() {
+		if e.ID == id {
+			current, found = e.Text, true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no learning with id %d", id)
+	}
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return "", fmt.Errorf("no text given and $EDITOR is not set")
+	}
+
+	f, err := os.CreateTemp("", "mochi-learning-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(current + "\n"); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	c := exec.Command("sh", "-c", editor+` "$0"`, f.Name())
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{learningsCmd, learningsListCmd} {
+		c.Flags().StringVar(&learningsFor, "for", "",
+			"Show only the learnings a task with this description would be given")
+	}
+	learningsPruneCmd.Flags().IntVar(&learningsOlderThan, "older-than", 0,
+		"Remove learnings not seen in this many days")
+	learningsPruneCmd.Flags().IntVar(&learningsMinCount, "min-count", 2,
+		"With --older-than, keep learnings seen at least this many times")
+
+	learningsCmd.AddCommand(learningsListCmd)
+	learningsCmd.AddCommand(learningsEditCmd)
+	learningsCmd.AddCommand(learningsPruneCmd)
+}
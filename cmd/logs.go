@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thisguymartin/ai-forge/internal/reviewer"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+var (
+	logsIter     int
+	logsReviewer bool
+	logsFollow   bool
+	logsLines    int
+)
+
+// followInterval is how often --follow checks the logs for new output.
+const followInterval = 500 * time.Millisecond
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <slug>",
+	Short: "Print or follow the logs of a task",
+	Long: `Prints the agent log of a task: <log-dir>/<slug>.log for a single-pass run,
+or <slug>-iterN.log for the latest Ralph Loop iteration (or the one chosen
+with --iter). With --reviewer, prints that iteration's reviewer logs instead
+(<slug>-reviewer-iterN.log, one per reviewer).
+
+With --follow, keeps printing as the log grows and moves on to the next
+iteration's log when it appears, until the task finishes or Ctrl-C.`,
+	Example: `  mochi logs fix-mobile-navbar
+  mochi logs fix-mobile-navbar --iter 2 --reviewer
+  mochi logs fix-mobile-navbar --follow`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+		entries, err := manifestEntries()
+		if err != nil {
+			return err
+		}
+		entry := worktree.FindEntry(entries, slug, runFilter)
+
+		// Runs write their logs relative to the repo root.
+		dir := cfg.LogDir
+		if !filepath.IsAbs(dir) {
+			root, err := repoRoot()
+			if err != nil {
+				return err
+			}
+			dir = filepath.Join(root, dir)
+		}
+		f := logFinder{dir: dir, slug: slug, iter: logsIter, reviewer: logsReviewer}
+		cmd.SilenceUsage = true
+
+		if !logsFollow {
+			paths, err := f.find()
+			if err != nil {
+				return err
+			}
+			for i, path := range paths {
+				if len(paths) > 1 {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("==> %s <==\n", path)
+				}
+				if _, err := printTail(os.Stdout, path, logsLines); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		running := func() bool {
+			entries, err := manifestEntries()
+			if err != nil || entry == nil {
+				return false
+			}
+			e := worktree.FindEntry(entries, slug, entry.RunID)
+			return e != nil && (e.Status == "pending" || e.Status == "running")
+		}
+		return followLogs(cmd.Context(), os.Stdout, f, running, logsLines)
+	},
+}
+
+// logFinder locates the log files of one task.
+type logFinder struct {
+	dir      string
+	slug     string
+	iter     int // 0 = latest
+	reviewer bool
+}
+
+// find returns the worker log of the chosen iteration or, for reviewer logs,
+// every reviewer's log of it.
+func (f logFinder) find() ([]string, error) {
+	names, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read log dir: %w", err)
+	}
+
+	// Worker logs are <slug>.log (single pass) or <slug>-iterN.log; reviewer
+	// logs are <slug>-reviewer-iterN.log, plus -<model> with several reviewers.
+	pattern := regexp.QuoteMeta(f.slug) + `-iter(\d+)\.log`
+	if f.reviewer {
+		prefix := strings.TrimSuffix(reviewer.LogName(f.slug, 0), "0")
+		pattern = regexp.QuoteMeta(prefix) + `(\d+)(?:-.+)?\.log`
+	}
+	re := regexp.MustCompile("^" + pattern + "$")
+
+	byIter := make(map[int][]string)
+	latest := 0
+	for _, n := range names {
+		m := re.FindStringSubmatch(n.Name())
+		if m == nil {
+			continue
+		}
+		iter, _ := strconv.Atoi(m[1])
+		byIter[iter] = append(byIter[iter], filepath.Join(f.dir, n.Name()))
+		latest = max(latest, iter)
+	}
+	if !f.reviewer {
+		if _, err := os.Stat(filepath.Join(f.dir, f.slug+".log")); err == nil && len(byIter[1]) == 0 {
+			byIter[1] = []string{filepath.Join(f.dir, f.slug+".log")}
+			latest = max(latest, 1)
+		}
+	}
+
+	iter := f.iter
+	if iter == 0 {
+		iter = latest
+	}
+	paths := byIter[iter]
+	if len(paths) == 0 {
+		kind := "agent"
+		if f.reviewer {
+			kind = "reviewer"
+		}
+		if f.iter > 0 {
+			return nil, fmt.Errorf("no %s log for %s iteration %d in %s", kind, f.slug, f.iter, f.dir)
+		}
+		return nil, fmt.Errorf("no %s log for %s in %s", kind, f.slug, f.dir)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// printTail writes the last n lines of the file at path to w, or all of it
+// when n is 0, and returns the file size.
+func printTail(w io.Writer, path string, n int) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	out := data
+	if n > 0 {
+		lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) > n {
+			out = []byte(strings.Join(lines[len(lines)-n:], "") + "\n")
+		}
+	}
+	_, err = w.Write(out)
+	return int64(len(data)), err
+}
+
+// followLogs prints the task's logs as they grow, switching to newer
+// iterations as their logs appear, until running reports false and nothing
+// new was written, or ctx is cancelled.
+func followLogs(ctx context.Context, w io.Writer, f logFinder, running func() bool, lines int) error {
+	offsets := make(map[string]int64)
+	current := ""
+	for {
+		paths, err := f.find()
+		wrote := false
+		if err == nil {
+			for _, path := range paths {
+				grew, err := printNew(w, path, offsets, lines, func() {
+					if current != path && (len(paths) > 1 || current != "") {
+						fmt.Fprintf(w, "\n==> %s <==\n", path)
+					}
+					current = path
+				})
+				if err != nil {
+					return err
+				}
+				wrote = wrote || grew
+			}
+		}
+		if !wrote && !running() {
+			if err != nil && len(offsets) == 0 {
+				return err
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// printNew writes what was appended to path since the last call, or its last
+// lines the first time it is seen, and reports whether the log is new or grew.
+// header runs before anything is written.
+func printNew(w io.Writer, path string, offsets map[string]int64, lines int, header func()) (bool, error) {
+	offset, seen := offsets[path]
+	if !seen {
+		header()
+		size, err := printTail(w, path, lines)
+		offsets[path] = size
+		return true, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() < offset {
+		offset = 0 // rewritten
+	}
+	if info.Size() == offset {
+		return false, nil
+	}
+	header()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	n, err := io.Copy(w, file)
+	offsets[path] = offset + n
+	return true, err
+}
+
+func init() {
+	logsCmd.Flags().IntVar(&logsIter, "iter", 0, "Ralph Loop iteration (default: the latest)")
+	logsCmd.Flags().BoolVar(&logsReviewer, "reviewer", false, "Show the reviewer logs instead of the agent log")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new output until the task finishes")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 0, "Only print the last N lines of each log (0 = all)")
+	logsCmd.Flags().StringVar(&runFilter, "run", "", "Run of the task (default: the most recent run that has it)")
+}
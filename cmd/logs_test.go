@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/reviewer"
+)
+
+func TestLogFinder(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"fix-navbar-iter1.log",
+		"fix-navbar-iter2.log",
+		"fix-navbar-iter10.log",
+		"fix-navbar-extra-iter3.log", // another task whose slug starts the same
+		"fix-navbar-setup.log",
+		reviewer.LogName("fix-navbar", 1) + ".log",
+		reviewer.LogName("fix-navbar", 2) + "-claude-opus-4-6.log",
+		reviewer.LogName("fix-navbar", 2) + "-gemini-2-5-pro.log",
+		"other.log",
+	} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644)
+	}
+
+	tests := []struct {
+		name string
+		f    logFinder
+		want []string
+	}{
+		{"latest iteration", logFinder{iter: 0}, []string{"fix-navbar-iter10.log"}},
+		{"chosen iteration", logFinder{iter: 2}, []string{"fix-navbar-iter2.log"}},
+		{"reviewer", logFinder{iter: 1, reviewer: true}, []string{reviewer.LogName("fix-navbar", 1) + ".log"}},
+		{"consensus reviewers", logFinder{reviewer: true}, []string{
+			reviewer.LogName("fix-navbar", 2) + "-claude-opus-4-6.log",
+			reviewer.LogName("fix-navbar", 2) + "-gemini-2-5-pro.log",
+		}},
+	}
+	for _, tt := range tests {
+		f := tt.f
+		f.dir, f.slug = dir, "fix-navbar"
+		got, err := f.find()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for i := range got {
+			got[i] = filepath.Base(got[i])
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v; want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := (logFinder{dir: dir, slug: "fix-navbar", iter: 4}).find(); err == nil {
+		t.Error("expected an error for a missing iteration")
+	}
+
+	// A single-pass run writes <slug>.log.
+	os.WriteFile(filepath.Join(dir, "other-task.log"), []byte("done\n"), 0644)
+	if got, err := (logFinder{dir: dir, slug: "other-task"}).find(); err != nil || filepath.Base(got[0]) != "other-task.log" {
+		t.Errorf("single-pass log = %v, %v", got, err)
+	}
+}
+
+func TestFollowLogs_StopsWhenTaskFinishes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "add-auth-iter1.log"), []byte("one\ntwo\nthree\n"), 0644)
+
+	polls := 0
+	running := func() bool {
+		polls++
+		if polls == 1 {
+			os.WriteFile(filepath.Join(dir, "add-auth-iter2.log"), []byte("retrying\n"), 0644)
+		}
+		return polls < 2
+	}
+	var out bytes.Buffer
+	err := followLogs(context.Background(), &out, logFinder{dir: dir, slug: "add-auth"}, running, 2)
+	if err != nil {
+		t.Fatalf("followLogs failed: %v", err)
+	}
+	got := out.String()
+	if !strings.HasPrefix(got, "two\nthree\n") || !strings.Contains(got, "==> "+filepath.Join(dir, "add-auth-iter2.log")+" <==\nretrying\n") {
+		t.Errorf("output = %q", got)
+	}
+}
@@ -1,20 +1,30 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/thisguymartin/ai-forge/internal/config"
-	"github.com/thisguymartin/ai-forge/internal/orchestrator"
 	"github.com/thisguymartin/ai-forge/internal/tui"
 	"github.com/thisguymartin/ai-forge/internal/worktree"
+	"github.com/thisguymartin/ai-forge/pkg/mochi"
 )
 
 const Version = "0.1.0"
 
 var cfg config.Config
 
+// configErr records a failure to load the config files or MOCHI_* environment
+// during init so it can be reported once a command actually runs.
+var configErr error
+
 var rootCmd = &cobra.Command{
 	Use:   "mochi",
 	Short: "Multi-task AI coding orchestrator",
@@ -42,6 +52,11 @@ Supported providers (auto-detected from model name):
 
   # Debug a single task sequentially with live output
   mochi --prd examples/PRD.md --task fix-mobile-navbar --sequential --verbose`,
+	// Execute reports errors itself.
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return configErr
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no task source was explicitly provided, show the info panel and exit.
 		hasInput := cmd.Flags().Changed("prd") || cmd.Flags().Changed("input") || cmd.Flags().Changed("plan")
@@ -60,25 +75,45 @@ Supported providers (auto-detected from model name):
 			cfg.Model = selected
 		}
 
+		// A task file named on the command line is relative to where the
+		// command runs; one from the config is relative to the repo root.
+		if hasInput && !filepath.IsAbs(cfg.InputFile) {
+			abs, err := filepath.Abs(cfg.InputFile)
+			if err != nil {
+				return err
+			}
+			cfg.InputFile = abs
+		}
+
 		tui.RunSplash()
-		return orchestrator.Run(cfg)
+		// Flags are valid from here on; a failed run should not print usage.
+		cmd.SilenceUsage = true
+		runner, err := newRunner()
+		if err != nil {
+			return err
+		}
+		return checkRun(runner.Run(cmd.Context()))
 	},
 }
 
+// runFilter is the --run flag of prune and cleanup.
+var runFilter string
+
 var pruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Remove stale worktree registrations and manifest entries",
 	Long: `Runs 'git worktree prune' to clear git's stale registrations, then removes
-any manifest entries whose paths no longer exist on disk.
+any manifest entries whose paths no longer exist on disk. With --run, only
+entries of that run are removed.
 
 Use this after a crashed or interrupted run leaves orphaned worktree state.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		repoRoot, err := os.Getwd()
+		root, err := repoRoot()
 		if err != nil {
 			return err
 		}
-		defaults := config.Default()
-		wm := worktree.NewManager(repoRoot, defaults.BaseBranch, defaults.BranchPrefix, defaults.WorktreeDir)
+		wm := worktree.NewManager(root, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+		wm.RunID = runFilter
 		pruned, err := wm.Prune()
 		if err != nil {
 			return err
@@ -95,16 +130,188 @@ Use this after a crashed or interrupted run leaves orphaned worktree state.`,
 	},
 }
 
-// Execute is the entry point called by main.
+var cleanupAll bool
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove the worktrees and branches of a run",
+	Long: `Removes the worktrees, local branches and manifest entries left behind by
+one run (--run <id>), e.g. one started with --keep-worktrees or cancelled,
+or by every run (--all). Other runs in the same repo are not touched.
+Pushed branches and open PRs are kept.`,
+	Example: `  # Remove what run 20260312-141502-9f3a left behind
+  mochi cleanup --run 20260312-141502-9f3a`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := repoRoot()
+		if err != nil {
+			return err
+		}
+		wm := worktree.NewManager(root, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+		entries, err := wm.Entries()
+		if err != nil {
+			return fmt.Errorf("cannot read worktree manifest: %w", err)
+		}
+		if !cleanupAll {
+			if runFilter == "" {
+				return fmt.Errorf("pass --run <id> or --all (runs in the manifest: %s)", runList(entries))
+			}
+			entries = worktree.RunEntries(entries, runFilter)
+			if len(entries) == 0 {
+				return fmt.Errorf("no worktrees recorded for run %s", runFilter)
+			}
+		}
+		cmd.SilenceUsage = true
+
+		failed := 0
+		for _, e := range entries {
+			wm.RunID = e.RunID
+			if err := wm.Destroy(e.Slug); err != nil {
+				fmt.Fprintf(os.Stderr, "  failed   %s: %v\n", e.Slug, err)
+				failed++
+				continue
+			}
+			fmt.Printf("  removed  %s (%s)\n", e.Slug, e.Branch)
+		}
+		fmt.Printf("Removed %d worktree(s).\n", len(entries)-failed)
+		if failed > 0 {
+			return fmt.Errorf("%d worktree(s) could not be removed; try 'mochi prune'", failed)
+		}
+		return nil
+	},
+}
+
+// runList names the runs recorded in entries, or "none".
+func runList(entries []*worktree.Entry) string {
+	var runs []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.RunID != "" && !seen[e.RunID] {
+			seen[e.RunID] = true
+			runs = append(runs, e.RunID)
+		}
+	}
+	if len(runs) == 0 {
+		return "none"
+	}
+	return strings.Join(runs, ", ")
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume an interrupted run from the worktree manifest",
+	Long: `Reloads .mochi_manifest.json and continues the run it describes. Tasks
+already marked done are skipped; running, failed, skipped and cancelled tasks
+re-enter the Ralph Loop in their existing worktrees, using the memory files left
+behind by the previous run. Output dispatch and PR creation then proceed as usual.
+
+Without --run, the most recent run in the manifest is resumed. Run flags
+such as --reviewer-model, --max-iterations, --output-mode and --create-prs
+apply to the resumed run.`,
+	Example: `  # Pick up where a crashed run left off and open PRs
+  mochi resume --create-prs
+
+  # Resume an older run while a newer one is still going
+  mochi resume --run 20260312-141502-9f3a`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		runner, err := newRunner()
+		if err != nil {
+			return err
+		}
+		return checkRun(runner.Resume(cmd.Context()))
+	},
+}
+
+var retryInstructions string
+
+var retryCmd = &cobra.Command{
+	Use:   "retry <slug>",
+	Short: "Run one failed task again in its existing worktree",
+	Long: `Runs a single task again in the worktree and branch its run left behind, so
+the new attempt starts from the previous one's commits and memory files. Failed
+and skipped tasks keep their worktrees for this when the run used --keep-failed.
+
+--model switches the task to another model and --instructions adds guidance to
+the task description, for this attempt only. The Ralph Loop restarts at
+iteration 1, then the output or PR step runs for this task; if the task already
+has a PR, the new commits are pushed to it.`,
+	Example: `  mochi retry fix-mobile-navbar
+  mochi retry fix-mobile-navbar --model claude-opus-4-6 --instructions "The navbar test lives in e2e/"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		retry := mochi.RetryOptions{Slug: args[0], Instructions: retryInstructions}
+		if cmd.Flags().Changed("model") {
+			retry.Model = cfg.Model
+		}
+		cmd.SilenceUsage = true
+		runner, err := newRunner()
+		if err != nil {
+			return err
+		}
+		return checkRun(runner.Retry(cmd.Context(), retry))
+	},
+}
+
+// repoRoot returns the top level of the git repository around the current
+// directory, so its .mochi/ config, learnings and manifest are found from any
+// subdirectory. Outside a repository it is the current directory.
+func repoRoot() (string, error) {
+	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+	return os.Getwd()
+}
+
+// newRunner returns a Runner for the repository around the current directory.
+func newRunner() (*mochi.Runner, error) {
+	root, err := repoRoot()
+	if err != nil {
+		return nil, err
+	}
+	return mochi.NewRunner(mochi.Options{Config: cfg, RepoRoot: root}), nil
+}
+
+// checkRun turns failed tasks into an error so the command exits non-zero
+// (CI-compatible).
+func checkRun(res mochi.RunResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if res.Failed > 0 {
+		return fmt.Errorf("%d of %d task(s) failed", res.Failed, len(res.Tasks))
+	}
+	return nil
+}
+
+// Execute is the entry point called by main. The first SIGINT or SIGTERM
+// cancels the run gracefully; a second one terminates immediately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// stop is only called once a signal arrives; the process exits otherwise.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop() // restore default handling so a second signal kills the process
+		fmt.Fprintln(os.Stderr, "\nCancelling — stopping agents (press Ctrl-C again to force quit)")
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func init() {
+	// Flag defaults come from the layered config (user file < repo file < env),
+	// so an explicitly passed flag always wins.
 	defaults := config.Default()
+	root, err := repoRoot()
+	if err == nil {
+		defaults, err = config.Load(root)
+	}
+	if err != nil {
+		configErr = err
+		defaults = config.Default()
+	}
 
 	// Input source
 	rootCmd.Flags().StringVarP(&cfg.InputFile, "input", "i", defaults.InputFile,
@@ -116,54 +323,115 @@ func init() {
 	rootCmd.Flags().IntVar(&cfg.IssueNumber, "issue", 0,
 		"Pull tasks from a GitHub Issue number (requires gh CLI)")
 
-	// Model
-	rootCmd.Flags().StringVar(&cfg.Model, "model", defaults.Model,
+	// Model (flags below are persistent so subcommands such as resume share them)
+	rootCmd.PersistentFlags().StringVar(&cfg.Model, "model", defaults.Model,
 		"Default model — Claude (claude-opus-4-6 | claude-sonnet-4-6 | claude-haiku-4-5) or Gemini (gemini-2.5-pro | gemini-2.0-flash)")
-	rootCmd.Flags().BoolVar(&cfg.PromptModel, "prompt-model", false,
+	rootCmd.Flags().BoolVar(&cfg.PromptModel, "prompt-model", defaults.PromptModel,
 		"Show interactive model picker before running")
 
 	// Execution control
 	rootCmd.Flags().BoolVar(&cfg.DryRun, "dry-run", false,
 		"Preview what would run without making any changes")
-	rootCmd.Flags().BoolVar(&cfg.Sequential, "sequential", false,
+	rootCmd.PersistentFlags().BoolVar(&cfg.Sequential, "sequential", defaults.Sequential,
 		"Run tasks one at a time instead of in parallel (useful for debugging)")
-	rootCmd.Flags().IntVar(&cfg.MaxWorktrees, "worktrees", defaults.MaxWorktrees,
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxWorktrees, "worktrees", defaults.MaxWorktrees,
 		"Max concurrent worktrees (0 = unlimited, matches task count)")
 	rootCmd.Flags().StringVar(&cfg.TaskFilter, "task", "",
 		"Run only the task matching this slug (e.g. fix-mobile-navbar)")
-	rootCmd.Flags().IntVar(&cfg.Timeout, "timeout", defaults.Timeout,
+	rootCmd.PersistentFlags().IntVar(&cfg.Timeout, "timeout", defaults.Timeout,
 		"Maximum time in seconds to wait for a single agent")
-	rootCmd.Flags().BoolVar(&cfg.Verbose, "verbose", false,
+	rootCmd.PersistentFlags().BoolVar(&cfg.Verbose, "verbose", defaults.Verbose,
 		"Stream agent output live to the terminal in addition to the log file")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Dashboard, "dashboard", defaults.Dashboard,
+		"Show a live full-screen task dashboard while agents run (TTY only)")
 
 	// GitHub
-	rootCmd.Flags().BoolVar(&cfg.CreatePRs, "create-prs", false,
+	rootCmd.PersistentFlags().BoolVar(&cfg.CreatePRs, "create-prs", defaults.CreatePRs,
 		"Push branches and open a GitHub PR for each completed task")
 
 	// Worktree
-	rootCmd.Flags().BoolVar(&cfg.KeepWorktrees, "keep-worktrees", false,
+	rootCmd.PersistentFlags().BoolVar(&cfg.KeepWorktrees, "keep-worktrees", defaults.KeepWorktrees,
 		"Keep worktrees on disk after the run (default: remove them)")
-	rootCmd.Flags().StringVar(&cfg.BaseBranch, "base-branch", defaults.BaseBranch,
+	rootCmd.PersistentFlags().BoolVar(&cfg.KeepOnCancel, "keep-on-cancel", defaults.KeepOnCancel,
+		"Keep worktrees of a cancelled run so it can be resumed")
+	rootCmd.PersistentFlags().BoolVar(&cfg.KeepFailed, "keep-failed", defaults.KeepFailed,
+		"Keep worktrees of failed and skipped tasks so they can be retried with 'mochi retry'")
+	rootCmd.PersistentFlags().StringVar(&cfg.BaseBranch, "base-branch", defaults.BaseBranch,
 		"Branch to base each worktree on")
+	rootCmd.PersistentFlags().StringVar(&cfg.BranchPrefix, "branch-prefix", defaults.BranchPrefix,
+		"Prefix for task branch names (<prefix>/<slug>)")
+	rootCmd.PersistentFlags().StringVar(&cfg.WorktreeDir, "worktree-dir", defaults.WorktreeDir,
+		"Directory where task worktrees are created")
+	rootCmd.PersistentFlags().StringVar(&cfg.LogDir, "log-dir", defaults.LogDir,
+		"Directory for agent and reviewer logs")
 
 	// Workspace (ai-native-dev integration)
-	rootCmd.Flags().StringVar(&cfg.Workspace, "workspace", "",
+	rootCmd.Flags().StringVar(&cfg.Workspace, "workspace", defaults.Workspace,
 		"Launch ai-native-dev workspace with worktree panes (zellij | auto)")
 
 	// Git  Loop
-	rootCmd.Flags().StringVar(&cfg.ReviewerModel, "reviewer-model", "",
+	rootCmd.PersistentFlags().StringVar(&cfg.ReviewerModel, "reviewer-model", defaults.ReviewerModel,
 		"Model for the reviewer agent — enables the Ralph Loop when set (e.g. claude-opus-4-6)")
-	rootCmd.Flags().IntVar(&cfg.MaxIterations, "max-iterations", defaults.MaxIterations,
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.ReviewerModels, "reviewers", defaults.ReviewerModels,
+		"Additional reviewer model run concurrently for consensus review (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ReviewPolicy, "review-policy", defaults.ReviewPolicy,
+		"How multiple reviewers reach a verdict: unanimous | majority | any-blocking")
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxIterations, "max-iterations", defaults.MaxIterations,
 		"Maximum worker iterations per task (default: 1, no loop)")
-	rootCmd.Flags().StringVar(&cfg.OutputMode, "output-mode", defaults.OutputMode,
+	rootCmd.PersistentFlags().StringVar(&cfg.SummaryModel, "summary-model", defaults.SummaryModel,
+		"Cheap model that summarizes older Ralph Loop iterations into a digest (e.g. claude-haiku-4-5)")
+	rootCmd.PersistentFlags().StringVar(&cfg.OutputMode, "output-mode", defaults.OutputMode,
 		"Output mode: pr | research-report | audit | knowledge-base | issue | file")
-	rootCmd.Flags().StringVar(&cfg.OutputDir, "output-dir", defaults.OutputDir,
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.Verify, "verify", defaults.Verify,
+		"Command that must pass in each worktree after every worker iteration (repeatable, e.g. --verify 'go test ./...')")
+	rootCmd.PersistentFlags().IntVar(&cfg.ReviewDiffLimit, "review-diff-limit", defaults.ReviewDiffLimit,
+		"Max bytes of the task branch diff included in the reviewer prompt")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.ReviewExclude, "review-exclude", defaults.ReviewExclude,
+		"Path pattern left out of the reviewer diff (repeatable, e.g. --review-exclude '*.lock')")
+	rootCmd.PersistentFlags().StringVar(&cfg.ReviewFormat, "review-format", defaults.ReviewFormat,
+		"Reviewer verdict format: text (DONE/RETRY) | json (scores, blocking issues, per-file comments)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.ReviewRubric, "review-rubric", defaults.ReviewRubric,
+		"Criterion scored in json review verdicts (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&cfg.OutputDir, "output-dir", defaults.OutputDir,
 		"Directory for file/report outputs (used with --output-mode file or research-report)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.IssueLabels, "issue-label", defaults.IssueLabels,
+		"Label added to issues filed with --output-mode issue (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&cfg.IssueAssignees, "issue-assignee", defaults.IssueAssignees,
+		"Assignee of issues filed with --output-mode issue (repeatable, e.g. @me)")
+
+	// Learnings
+	rootCmd.PersistentFlags().BoolVar(&cfg.Learnings, "learnings", defaults.Learnings,
+		"Feed learnings from earlier runs to workers and collect new ones in .mochi/learnings.json")
+	rootCmd.PersistentFlags().IntVar(&cfg.LearningsLimit, "learnings-limit", defaults.LearningsLimit,
+		"Max learnings injected into each task's prompt")
+
+	// Run reports
+	rootCmd.PersistentFlags().StringVar(&cfg.ReportJSON, "report-json", defaults.ReportJSON,
+		"Write a JSON record of the run (tasks, results, verification, reviews) to this path")
+	rootCmd.PersistentFlags().StringVar(&cfg.ReportJUnit, "report-junit", defaults.ReportJUnit,
+		"Write the run as JUnit XML, one test case per task, to this path")
+
+	// Events
+	rootCmd.PersistentFlags().StringVar(&cfg.EventLog, "event-log", defaults.EventLog,
+		"Append every lifecycle event to this file as newline-delimited JSON")
+
+	// Apply config-only settings that have no flag
+	cfg.Providers = defaults.Providers
+	cfg.Hooks = defaults.Hooks
+	cfg.Setup = defaults.Setup
 
-	// Apply non-flag defaults that don't need user exposure
-	cfg.BranchPrefix = defaults.BranchPrefix
-	cfg.WorktreeDir = defaults.WorktreeDir
-	cfg.LogDir = defaults.LogDir
+	pruneCmd.Flags().StringVar(&runFilter, "run", "", "Only prune entries of this run")
+	cleanupCmd.Flags().StringVar(&runFilter, "run", "", "Run whose worktrees to remove")
+	cleanupCmd.Flags().BoolVar(&cleanupAll, "all", false, "Remove the worktrees of every run")
+	resumeCmd.Flags().StringVar(&cfg.RunID, "run", "", "Run to resume (default: the most recent)")
+	retryCmd.Flags().StringVar(&cfg.RunID, "run", "", "Run of the task (default: the most recent run that has it)")
+	retryCmd.Flags().StringVar(&retryInstructions, "instructions", "", "Extra instructions added to the task for this attempt")
 
 	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(retryCmd)
+	rootCmd.AddCommand(learningsCmd)
 }
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the worktrees recorded in the manifest",
+	Long: `Lists every task recorded in .mochi_manifest.json: its slug, branch, status,
+run ID, age, last Ralph Loop iteration and PR URL. With --run, only that run's
+tasks are shown; --json prints the same fields as JSON for scripts.`,
+	Example: `  mochi status
+  mochi status --run 20260312-141502-9f3a --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := manifestEntries()
+		if err != nil {
+			return err
+		}
+		if runFilter != "" {
+			entries = worktree.RunEntries(entries, runFilter)
+		}
+
+		now := time.Now()
+		if statusJSON {
+			rows := make([]taskStatus, len(entries))
+			for i, e := range entries {
+				rows[i] = newTaskStatus(e, now)
+			}
+			data, err := json.MarshalIndent(rows, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No worktrees recorded.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SLUG\tBRANCH\tSTATUS\tRUN\tAGE\tITER\tPR")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Slug, e.Branch, e.Status, orDash(e.RunID), age(e.Created, now), iteration(e.Iteration), orDash(e.PRURL))
+		}
+		return w.Flush()
+	},
+}
+
+// taskStatus is one row of 'mochi status --json'.
+type taskStatus struct {
+	Slug       string     `json:"slug"`
+	Branch     string     `json:"branch"`
+	Status     string     `json:"status"`
+	RunID      string     `json:"run_id,omitempty"`
+	Created    *time.Time `json:"created,omitempty"`
+	AgeSeconds int64      `json:"age_seconds,omitempty"`
+	Iteration  int        `json:"iteration"`
+	PRURL      string     `json:"pr_url,omitempty"`
+	Path       string     `json:"path"`
+}
+
+func newTaskStatus(e *worktree.Entry, now time.Time) taskStatus {
+	s := taskStatus{
+		Slug:      e.Slug,
+		Branch:    e.Branch,
+		Status:    e.Status,
+		RunID:     e.RunID,
+		Iteration: e.Iteration,
+		PRURL:     e.PRURL,
+		Path:      e.Path,
+	}
+	if !e.Created.IsZero() {
+		created := e.Created
+		s.Created = &created
+		s.AgeSeconds = int64(now.Sub(created).Seconds())
+	}
+	return s
+}
+
+// manifestEntries reads every entry of the worktree manifest of the current
+// repository.
+func manifestEntries() ([]*worktree.Entry, error) {
+	root, err := repoRoot()
+	if err != nil {
+		return nil, err
+	}
+	wm := worktree.NewManager(root, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+	entries, err := wm.Entries()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read worktree manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// age renders how long ago t was, in its largest whole unit.
+func age(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func iteration(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&runFilter, "run", "", "Only show tasks of this run")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the tasks as JSON")
+}
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/thisguymartin/ai-forge/internal/memory"
+	"github.com/thisguymartin/ai-forge/internal/proc"
+	"github.com/thisguymartin/ai-forge/internal/verify"
 )
 
 // InvokeOptions configures a single agent invocation.
@@ -26,6 +28,12 @@ type InvokeOptions struct {
 	Iteration     int
 	MaxIterations int
 	MemoryContext memory.Context
+	Learnings     []string  // lessons from earlier runs in this repository
+	Providers     *Registry // resolves Model; nil means the registered providers
+
+	// OutputInstructions replace the commit instructions of the prompt for
+	// output modes whose result is a report rather than code changes.
+	OutputInstructions string
 }
 
 // Result captures the outcome of a single agent run.
@@ -36,6 +44,9 @@ type Result struct {
 	LogPath  string
 	Error    error
 	Output   string
+
+	// Verification holds the post-agent verification command results, if any.
+	Verification []verify.Result
 }
 
 const promptTmpl = `You are an AI coding agent working inside a git worktree.
@@ -44,6 +55,14 @@ Worktree path: {{.WorktreePath}}
 Current branch: {{.Branch}}
 
 Your task: {{.Task}}
+{{- if .Learnings}}
+
+=== LEARNINGS FROM EARLIER RUNS IN THIS REPOSITORY ===
+{{- range .Learnings}}
+- {{.}}
+{{- end}}
+=== END LEARNINGS ===
+{{- end}}
 {{- if .HasMemory}}
 
 === CONTEXT FROM PREVIOUS ITERATIONS ===
@@ -62,6 +81,11 @@ Your task: {{.Task}}
 ## Agent Learnings:
 {{.Agents}}
 {{- end}}
+{{- if .History}}
+
+## Earlier Iterations:
+{{.History}}
+{{- end}}
 === END CONTEXT ===
 
 IMPORTANT: You are on iteration {{.Iteration}} of {{.MaxIterations}}. Review the feedback above and address all noted issues before proceeding.
@@ -69,60 +93,50 @@ IMPORTANT: You are on iteration {{.Iteration}} of {{.MaxIterations}}. Review the
 
 Instructions:
 - Focus exclusively on the described task.
+{{- if .OutputInstructions}}
+{{.OutputInstructions}}
+{{- else}}
 - Do not modify files unrelated to this task.
 - When finished, commit all changes with a clear, descriptive commit message.
 - If the task cannot be completed, create a file named MOCHI_NOTES.md explaining why.
+{{- end}}
+- If you discover something about this repository that future tasks should know (build or test commands, conventions, pitfalls), state it in your final response on its own line starting with "LEARNING:".
 
 Begin now.`
 
 type promptData struct {
-	WorktreePath  string
-	Branch        string
-	Task          string
-	HasMemory     bool
-	Feedback      string
-	Progress      string
-	Agents        string
-	Iteration     int
-	MaxIterations int
+	WorktreePath       string
+	Branch             string
+	Task               string
+	HasMemory          bool
+	Feedback           string
+	Progress           string
+	Agents             string
+	Learnings          []string
+	History            string
+	Iteration          int
+	OutputInstructions string
+	MaxIterations      int
 }
 
-// providerFor returns "gemini" if the model name starts with "gemini-",
-// otherwise defaults to "claude".
-func providerFor(model string) string {
-	if strings.HasPrefix(model, "gemini-") {
-		return "gemini"
+// LogPath returns the log file Invoke writes for the given task iteration.
+func LogPath(logDir, slug string, iteration, maxIterations int) string {
+	if iteration == 0 {
+		iteration = 1
 	}
-	return "claude"
-}
-
-// buildCommand constructs the provider-specific exec.Cmd for non-interactive use.
-//
-//	claude  → claude --dangerously-skip-permissions -p <prompt>
-//	gemini  → gemini --model <model> -p <prompt>
-func buildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
-	switch providerFor(model) {
-	case "gemini":
-		return exec.CommandContext(ctx, "gemini", "--model", model, "-p", prompt)
-	default:
-		return exec.CommandContext(ctx, "claude", "--dangerously-skip-permissions", "-p", prompt)
+	if maxIterations > 1 {
+		return filepath.Join(logDir, fmt.Sprintf("%s-iter%d.log", slug, iteration))
 	}
+	return filepath.Join(logDir, slug+".log")
 }
 
 // Invoke runs the appropriate AI CLI inside the worktree for the given task.
-// It writes all output to a log file and returns a Result.
-func Invoke(opts InvokeOptions, slug string) Result {
+// It writes all output to a log file and returns a Result. Cancelling ctx
+// stops the agent process.
+func Invoke(ctx context.Context, opts InvokeOptions, slug string) Result {
 	start := time.Now()
 
-	iteration := opts.Iteration
-	if iteration == 0 {
-		iteration = 1
-	}
-	logSuffix := slug
-	if opts.MaxIterations > 1 {
-		logSuffix = fmt.Sprintf("%s-iter%d", slug, iteration)
-	}
-	logPath := filepath.Join(opts.LogDir, logSuffix+".log")
+	logPath := LogPath(opts.LogDir, slug, opts.Iteration, opts.MaxIterations)
 
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
@@ -142,11 +156,13 @@ func Invoke(opts InvokeOptions, slug string) Result {
 
 	writeLogHeader(logFile, slug, opts.Model)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 	defer cancel()
 
-	cmd := buildCommand(ctx, opts.Model, prompt)
+	provider := opts.Providers.For(opts.Model)
+	cmd := provider.BuildCommand(ctx, opts.Model, prompt)
 	cmd.Dir = opts.WorktreePath
+	proc.KillGroupOnCancel(cmd, proc.Grace)
 
 	var outBuf bytes.Buffer
 	writers := []io.Writer{logFile, &outBuf}
@@ -161,7 +177,19 @@ func Invoke(opts InvokeOptions, slug string) Result {
 	duration := time.Since(start)
 	writeLogFooter(logFile, slug, opts.Model, duration, runErr)
 
-	output := outBuf.String()
+	raw := outBuf.String()
+	output := provider.ParseOutput(raw)
+
+	if ctx.Err() == context.Canceled {
+		return Result{
+			Slug:     slug,
+			Success:  false,
+			Duration: duration,
+			LogPath:  logPath,
+			Output:   output,
+			Error:    fmt.Errorf("agent cancelled: %w", context.Canceled),
+		}
+	}
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return Result{
@@ -174,7 +202,10 @@ func Invoke(opts InvokeOptions, slug string) Result {
 		}
 	}
 
-	if runErr != nil {
+	if !provider.Succeeded(raw, runErr) {
+		if runErr == nil {
+			runErr = fmt.Errorf("%s reported an unsuccessful run", provider.Name())
+		}
 		return Result{Slug: slug, Success: false, Duration: duration, LogPath: logPath, Output: output, Error: runErr}
 	}
 
@@ -199,15 +230,18 @@ func buildPrompt(opts InvokeOptions) (string, error) {
 	}
 
 	data := promptData{
-		WorktreePath:  opts.WorktreePath,
-		Branch:        branch,
-		Task:          opts.Task,
-		HasMemory:     opts.MemoryContext.HasAny(),
-		Feedback:      opts.MemoryContext.Feedback,
-		Progress:      opts.MemoryContext.Progress,
-		Agents:        opts.MemoryContext.Agents,
-		Iteration:     iteration,
-		MaxIterations: maxIter,
+		WorktreePath:       opts.WorktreePath,
+		Branch:             branch,
+		Task:               opts.Task,
+		HasMemory:          opts.MemoryContext.HasAny(),
+		Feedback:           opts.MemoryContext.Feedback,
+		Progress:           opts.MemoryContext.Progress,
+		Agents:             opts.MemoryContext.Agents,
+		Learnings:          opts.Learnings,
+		History:            opts.MemoryContext.History,
+		OutputInstructions: opts.OutputInstructions,
+		Iteration:          iteration,
+		MaxIterations:      maxIter,
 	}
 
 	var buf bytes.Buffer
@@ -244,8 +278,38 @@ func writeLogFooter(w io.Writer, slug, model string, d time.Duration, err error)
 		time.Now().Format("2006-01-02 15:04:05"), slug, model, d.Seconds(), status)
 }
 
+// Complete runs model once on prompt, outside any worktree, and returns its
+// parsed output. It is meant for short side tasks such as naming branches or
+// summarizing memory; cancelling ctx stops the model. providers resolves model
+// and may be nil.
+func Complete(ctx context.Context, providers *Registry, model, prompt string) (string, error) {
+	// Cancel on return so providers can release per-run resources (prompt files).
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	provider := providers.For(model)
+	cmd := provider.BuildCommand(ctx, model, prompt)
+	proc.KillGroupOnCancel(cmd, proc.Grace)
+
+	// We want to capture exactly what it outputs.
+	var outBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	// We discard stderr to avoid logging noise, unless it fails.
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+
+	runErr := cmd.Run()
+	if !provider.Succeeded(outBuf.String(), runErr) {
+		if runErr == nil {
+			runErr = fmt.Errorf("%s reported an unsuccessful run", provider.Name())
+		}
+		return "", fmt.Errorf("%w, stderr: %s", runErr, errBuf.String())
+	}
+	return provider.ParseOutput(outBuf.String()), nil
+}
+
 // GenerateTitle uses the AI model to generate a short, branch-safe slug for a complex task.
-func GenerateTitle(ctx context.Context, model, taskDesc string) (string, error) {
+func GenerateTitle(ctx context.Context, providers *Registry, model, taskDesc string) (string, error) {
 	// Instruct the model to generate a strict git branch name slug.
 	prompt := fmt.Sprintf(`You are a git branch name generator.
 I will give you a task description. You must output ONLY a valid git branch name that describes the core intent of the task.
@@ -259,20 +323,12 @@ Rules:
 Task description:
 %s`, taskDesc)
 
-	cmd := buildCommand(ctx, model, prompt)
-
-	// We want to capture exactly what it outputs.
-	var outBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	// We discard stderr to avoid logging noise, unless it fails.
-	var errBuf bytes.Buffer
-	cmd.Stderr = &errBuf
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("agent error generating title: %w, stderr: %s", err, errBuf.String())
+	out, err := Complete(ctx, providers, model, prompt)
+	if err != nil {
+		return "", fmt.Errorf("agent error generating title: %w", err)
 	}
 
-	slug := strings.TrimSpace(outBuf.String())
+	slug := strings.TrimSpace(out)
 	slug = strings.ToLower(slug)
 
 	// Double check and sanitize just in case the model hallucinates formatting
@@ -299,7 +355,7 @@ Task description:
 
 	finalSlug := safe.String()
 	if finalSlug == "" {
-		return "", fmt.Errorf("generated title was empty or contained no valid characters: %q", outBuf.String())
+		return "", fmt.Errorf("generated title was empty or contained no valid characters: %q", out)
 	}
 
 	return finalSlug, nil
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Provider adapts one AI CLI to MOCHI. The worker, the reviewer and branch title
+// generation all route through the provider selected for their model, so adding
+// support for a new CLI (Codex, aider, OpenCode, an Ollama wrapper, ...) only
+// requires implementing this interface and calling Register.
+type Provider interface {
+	// Name identifies the provider, e.g. "claude".
+	Name() string
+	// Binary is the executable that must be in PATH for the provider to work.
+	Binary() string
+	// Matches reports whether the provider handles the given model name.
+	Matches(model string) bool
+	// BuildCommand constructs the non-interactive command that runs prompt.
+	BuildCommand(ctx context.Context, model, prompt string) *exec.Cmd
+	// ParseOutput extracts the agent's answer from the raw CLI output.
+	ParseOutput(raw string) string
+	// Succeeded reports whether a run completed, given its raw output and exit error.
+	Succeeded(raw string, runErr error) bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = []Provider{geminiProvider{}, claudeProvider{}}
+)
+
+// Register adds p to the provider registry. Providers registered later take
+// precedence over earlier ones (including the built-ins) when several match.
+func Register(p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append([]Provider{p}, registry...)
+}
+
+// Providers returns the registered providers in lookup order.
+func Providers() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Provider(nil), registry...)
+}
+
+// ProviderFor returns the first registered provider that matches model.
+// Unrecognised models fall back to the claude provider.
+func ProviderFor(model string) Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, p := range registry {
+		if p.Matches(model) {
+			return p
+		}
+	}
+	return claudeProvider{}
+}
+
+// Registry resolves models for one run: it consults its own providers before
+// the registered ones, so providers declared in one run's config are not seen
+// by other runs in the process. A nil Registry uses the registered providers
+// only.
+type Registry struct {
+	providers []Provider
+}
+
+// NewRegistry returns a Registry holding ps. As with Register, providers later
+// in ps take precedence over earlier ones.
+func NewRegistry(ps ...Provider) *Registry {
+	r := &Registry{}
+	for _, p := range ps {
+		r.providers = append([]Provider{p}, r.providers...)
+	}
+	return r
+}
+
+// For returns the first provider of r, then of the registry, that matches
+// model, falling back to the claude provider like ProviderFor.
+func (r *Registry) For(model string) Provider {
+	if r != nil {
+		for _, p := range r.providers {
+			if p.Matches(model) {
+				return p
+			}
+		}
+	}
+	return ProviderFor(model)
+}
+
+// claudeProvider runs the Claude Code CLI:
+//
+//	claude --dangerously-skip-permissions -p <prompt>
+type claudeProvider struct{}
+
+func (claudeProvider) Name() string   { return "claude" }
+func (claudeProvider) Binary() string { return "claude" }
+
+func (claudeProvider) Matches(model string) bool {
+	return strings.HasPrefix(model, "claude-")
+}
+
+func (claudeProvider) BuildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
+	return exec.CommandContext(ctx, "claude", "--dangerously-skip-permissions", "-p", prompt)
+}
+
+func (claudeProvider) ParseOutput(raw string) string { return raw }
+
+func (claudeProvider) Succeeded(raw string, runErr error) bool { return runErr == nil }
+
+// geminiProvider runs the Gemini CLI:
+//
+//	gemini --model <model> -p <prompt>
+type geminiProvider struct{}
+
+func (geminiProvider) Name() string   { return "gemini" }
+func (geminiProvider) Binary() string { return "gemini" }
+
+func (geminiProvider) Matches(model string) bool {
+	return strings.HasPrefix(model, "gemini-")
+}
+
+func (geminiProvider) BuildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
+	return exec.CommandContext(ctx, "gemini", "--model", model, "-p", prompt)
+}
+
+func (geminiProvider) ParseOutput(raw string) string { return raw }
+
+func (geminiProvider) Succeeded(raw string, runErr error) bool { return runErr == nil }
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type fakeProvider struct{ prefix string }
+
+func (f fakeProvider) Name() string   { return "fake" }
+func (f fakeProvider) Binary() string { return "fake-cli" }
+
+func (f fakeProvider) Matches(model string) bool { return strings.HasPrefix(model, f.prefix) }
+
+func (f fakeProvider) BuildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
+	return exec.CommandContext(ctx, "fake-cli", model, prompt)
+}
+
+func (f fakeProvider) ParseOutput(raw string) string           { return strings.TrimSpace(raw) }
+func (f fakeProvider) Succeeded(raw string, runErr error) bool { return runErr == nil }
+
+func restoreRegistry(t *testing.T) {
+	t.Helper()
+	saved := Providers()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+}
+
+func TestProviderFor_BuiltIns(t *testing.T) {
+	cases := []struct {
+		model string
+		want  string
+	}{
+		{"claude-opus-4-6", "claude"},
+		{"gemini-2.5-pro", "gemini"},
+		{"some-unknown-model", "claude"},
+		{"", "claude"},
+	}
+	for _, tc := range cases {
+		if got := ProviderFor(tc.model).Name(); got != tc.want {
+			t.Errorf("ProviderFor(%q) = %q; want %q", tc.model, got, tc.want)
+		}
+	}
+}
+
+func TestRegister_TakesPrecedence(t *testing.T) {
+	restoreRegistry(t)
+
+	Register(fakeProvider{prefix: "gemini-"})
+
+	if got := ProviderFor("gemini-2.5-pro").Name(); got != "fake" {
+		t.Errorf("ProviderFor(gemini-2.5-pro) = %q; want fake to override built-in", got)
+	}
+	if got := ProviderFor("claude-opus-4-6").Name(); got != "claude" {
+		t.Errorf("ProviderFor(claude-opus-4-6) = %q; want claude", got)
+	}
+}
+
+func TestBuiltInCommands(t *testing.T) {
+	ctx := context.Background()
+
+	claude := ProviderFor("claude-sonnet-4-6").BuildCommand(ctx, "claude-sonnet-4-6", "do it")
+	if want := []string{"claude", "--dangerously-skip-permissions", "-p", "do it"}; strings.Join(claude.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("claude args = %v; want %v", claude.Args, want)
+	}
+
+	gemini := ProviderFor("gemini-2.0-flash").BuildCommand(ctx, "gemini-2.0-flash", "do it")
+	if want := []string{"gemini", "--model", "gemini-2.0-flash", "-p", "do it"}; strings.Join(gemini.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("gemini args = %v; want %v", gemini.Args, want)
+	}
+}
+
+func TestRegistry_ScopesProviders(t *testing.T) {
+	r := NewRegistry(fakeProvider{prefix: "gemini-"}, fakeProvider{prefix: "local-"})
+
+	for _, model := range []string{"gemini-2.5-pro", "local-llama"} {
+		if got := r.For(model).Name(); got != "fake" {
+			t.Errorf("For(%q) = %q; want fake", model, got)
+		}
+	}
+	if got := r.For("claude-opus-4-6").Name(); got != "claude" {
+		t.Errorf("For(claude-opus-4-6) = %q; want the built-in claude", got)
+	}
+	if got := ProviderFor("gemini-2.5-pro").Name(); got != "gemini" {
+		t.Errorf("ProviderFor(gemini-2.5-pro) = %q; a Registry must not change the global lookup", got)
+	}
+	if got := (*Registry)(nil).For("gemini-2.5-pro").Name(); got != "gemini" {
+		t.Errorf("nil Registry For(gemini-2.5-pro) = %q; want gemini", got)
+	}
+}
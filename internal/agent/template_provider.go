@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// TemplateSpec declares a provider as a command template instead of Go code,
+// e.g. in .mochi/config.yaml:
+//
+//	providers:
+//	  - name: mycli
+//	    match: '^mycli-'
+//	    command: mycli --model {{.Model}} --prompt-file {{.PromptFile}}
+//
+// The command is split into arguments (single and double quotes group words)
+// before each argument is rendered, so {{.Prompt}} is always passed as a
+// single argument regardless of its content.
+type TemplateSpec struct {
+	Name           string // provider name shown in logs and errors
+	Match          string // regexp selecting the models this provider handles
+	Command        string // text/template with .Model, .Prompt and .PromptFile
+	SuccessPattern string // optional regexp the output must match to count as success
+	OutputPattern  string // optional regexp; its first capture group becomes the output
+}
+
+// templateData is the data available to a TemplateSpec command.
+type templateData struct {
+	Model      string
+	Prompt     string
+	PromptFile string
+}
+
+type templateProvider struct {
+	name    string
+	match   *regexp.Regexp
+	args    []*template.Template
+	success *regexp.Regexp
+	output  *regexp.Regexp
+	// usesFile is set when the command references .PromptFile, so the prompt
+	// is only written to disk when needed.
+	usesFile bool
+}
+
+// NewTemplateProvider validates spec and returns a Provider that runs it.
+func NewTemplateProvider(spec TemplateSpec) (Provider, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("provider: name is required")
+	}
+	if spec.Match == "" {
+		return nil, fmt.Errorf("provider %q: match is required", spec.Name)
+	}
+	if strings.TrimSpace(spec.Command) == "" {
+		return nil, fmt.Errorf("provider %q: command is required", spec.Name)
+	}
+
+	p := &templateProvider{name: spec.Name}
+
+	var err error
+	if p.match, err = regexp.Compile(spec.Match); err != nil {
+		return nil, fmt.Errorf("provider %q: invalid match: %w", spec.Name, err)
+	}
+	if spec.SuccessPattern != "" {
+		if p.success, err = regexp.Compile(spec.SuccessPattern); err != nil {
+			return nil, fmt.Errorf("provider %q: invalid success_pattern: %w", spec.Name, err)
+		}
+	}
+	if spec.OutputPattern != "" {
+		if p.output, err = regexp.Compile(spec.OutputPattern); err != nil {
+			return nil, fmt.Errorf("provider %q: invalid output_pattern: %w", spec.Name, err)
+		}
+	}
+
+	words, err := splitCommand(spec.Command)
+	if err != nil {
+		return nil, fmt.Errorf("provider %q: %w", spec.Name, err)
+	}
+	for i, w := range words {
+		tmpl, err := template.New(fmt.Sprintf("%s-arg%d", spec.Name, i)).Option("missingkey=error").Parse(w)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: invalid command template: %w", spec.Name, err)
+		}
+		p.args = append(p.args, tmpl)
+		if strings.Contains(w, ".PromptFile") {
+			p.usesFile = true
+		}
+	}
+
+	return p, nil
+}
+
+func (p *templateProvider) Name() string { return p.name }
+
+// Binary returns the command's first word when it is a literal.
+func (p *templateProvider) Binary() string {
+	var buf bytes.Buffer
+	if err := p.args[0].Execute(&buf, templateData{}); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func (p *templateProvider) Matches(model string) bool { return p.match.MatchString(model) }
+
+// BuildCommand renders the command for model and prompt. When the template uses
+// .PromptFile, the prompt is written to a temp file that is removed once ctx is
+// done, so callers must cancel ctx after the command finishes.
+func (p *templateProvider) BuildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
+	data := templateData{Model: model, Prompt: prompt}
+
+	if p.usesFile {
+		f, err := os.CreateTemp("", "mochi-prompt-*.md")
+		if err != nil {
+			return failingCommand(ctx, fmt.Errorf("provider %q: cannot create prompt file: %w", p.name, err))
+		}
+		_, werr := f.WriteString(prompt)
+		f.Close()
+		if werr != nil {
+			os.Remove(f.Name())
+			return failingCommand(ctx, fmt.Errorf("provider %q: cannot write prompt file: %w", p.name, werr))
+		}
+		data.PromptFile = f.Name()
+		context.AfterFunc(ctx, func() { os.Remove(f.Name()) })
+	}
+
+	args := make([]string, len(p.args))
+	for i, tmpl := range p.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return failingCommand(ctx, fmt.Errorf("provider %q: cannot render command: %w", p.name, err))
+		}
+		args[i] = buf.String()
+	}
+	return exec.CommandContext(ctx, args[0], args[1:]...)
+}
+
+func (p *templateProvider) ParseOutput(raw string) string {
+	if p.output == nil {
+		return raw
+	}
+	if m := p.output.FindStringSubmatch(raw); len(m) > 1 {
+		return m[1]
+	}
+	return raw
+}
+
+func (p *templateProvider) Succeeded(raw string, runErr error) bool {
+	if runErr != nil {
+		return false
+	}
+	return p.success == nil || p.success.MatchString(raw)
+}
+
+// failingCommand returns a command whose Run reports err without executing
+// anything, so BuildCommand errors surface through the normal run path.
+func failingCommand(ctx context.Context, err error) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "mochi-provider-error")
+	cmd.Err = err
+	return cmd
+}
+
+// splitCommand splits a command line into words. Whitespace separates words
+// except inside single or double quotes or {{ ... }} template actions; quotes
+// themselves are removed.
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if strings.HasPrefix(s[i:], "{{") {
+			end := strings.Index(s[i:], "}}")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated {{ in command %q", s)
+			}
+			cur.WriteString(s[i : i+end+2])
+			inWord = true
+			i += end + 1
+			continue
+		}
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command %q", quote, s)
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return words, nil
+}
@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"mycli --model {{.Model}}", []string{"mycli", "--model", "{{.Model}}"}},
+		{"mycli --prompt {{ .Prompt }}", []string{"mycli", "--prompt", "{{ .Prompt }}"}},
+		{`sh -c 'echo "hi there"'`, []string{"sh", "-c", `echo "hi there"`}},
+		{`run "two words" three`, []string{"run", "two words", "three"}},
+	}
+	for _, tc := range cases {
+		got, err := splitCommand(tc.input)
+		if err != nil {
+			t.Errorf("splitCommand(%q) error: %v", tc.input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("splitCommand(%q) = %q; want %q", tc.input, got, tc.want)
+		}
+	}
+
+	if _, err := splitCommand(`mycli "unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestNewTemplateProvider_Validation(t *testing.T) {
+	cases := []TemplateSpec{
+		{Match: "^x-", Command: "x"},
+		{Name: "x", Command: "x"},
+		{Name: "x", Match: "^x-"},
+		{Name: "x", Match: "(", Command: "x"},
+		{Name: "x", Match: "^x-", Command: "x {{.Model"},
+	}
+	for _, spec := range cases {
+		if _, err := NewTemplateProvider(spec); err == nil {
+			t.Errorf("NewTemplateProvider(%+v) = nil error; want error", spec)
+		}
+	}
+}
+
+// writeStandIn creates an executable shell script that acts as a fake AI CLI.
+func writeStandIn(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-agent.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatalf("cannot write stand-in script: %v", err)
+	}
+	return path
+}
+
+func TestInvoke_TemplateProvider(t *testing.T) {
+	restoreRegistry(t)
+
+	script := writeStandIn(t, `echo "model=$2"
+cat "$4"
+echo "RESULT: all good"
+`)
+	p, err := NewTemplateProvider(TemplateSpec{
+		Name:           "fake",
+		Match:          "^fake-",
+		Command:        script + " --model {{.Model}} --prompt-file {{.PromptFile}}",
+		SuccessPattern: "RESULT: all good",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateProvider failed: %v", err)
+	}
+	Register(p)
+
+	logDir := t.TempDir()
+	result := Invoke(context.Background(), InvokeOptions{
+		WorktreePath: t.TempDir(),
+		Task:         "Write the docs",
+		Model:        "fake-1",
+		Timeout:      30,
+		LogDir:       logDir,
+	}, "write-the-docs")
+
+	if !result.Success {
+		t.Fatalf("Invoke failed: %v\n%s", result.Error, result.Output)
+	}
+	if !strings.Contains(result.Output, "model=fake-1") {
+		t.Errorf("output missing rendered model: %q", result.Output)
+	}
+	if !strings.Contains(result.Output, "Your task: Write the docs") {
+		t.Errorf("output missing prompt file contents: %q", result.Output)
+	}
+	if _, err := os.Stat(result.LogPath); err != nil {
+		t.Errorf("expected log file at %s: %v", result.LogPath, err)
+	}
+}
+
+func TestInvoke_TemplateProviderSuccessPattern(t *testing.T) {
+	restoreRegistry(t)
+
+	script := writeStandIn(t, "echo 'something went wrong'\n")
+	p, err := NewTemplateProvider(TemplateSpec{
+		Name:           "fake",
+		Match:          "^fake-",
+		Command:        script + " {{.Prompt}}",
+		SuccessPattern: "^DONE",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateProvider failed: %v", err)
+	}
+	Register(p)
+
+	result := Invoke(context.Background(), InvokeOptions{
+		WorktreePath: t.TempDir(),
+		Task:         "Anything",
+		Model:        "fake-1",
+		Timeout:      30,
+		LogDir:       t.TempDir(),
+	}, "anything")
+
+	if result.Success {
+		t.Error("expected failure when output does not match success_pattern")
+	}
+}
+
+func TestGenerateTitle_TemplateProvider(t *testing.T) {
+	restoreRegistry(t)
+
+	script := writeStandIn(t, "echo 'feature/Add-Login-Page'\n")
+	p, err := NewTemplateProvider(TemplateSpec{Name: "fake", Match: "^fake-", Command: script + " {{.Prompt}}"})
+	if err != nil {
+		t.Fatalf("NewTemplateProvider failed: %v", err)
+	}
+	Register(p)
+
+	got, err := GenerateTitle(context.Background(), nil, "fake-1", "Add a login page")
+	if err != nil {
+		t.Fatalf("GenerateTitle failed: %v", err)
+	}
+	if got != "add-login-page" {
+		t.Errorf("GenerateTitle = %q; want %q", got, "add-login-page")
+	}
+}
+
+func TestInvoke_Cancelled(t *testing.T) {
+	restoreRegistry(t)
+
+	script := writeStandIn(t, "exec sleep 30\n")
+	p, err := NewTemplateProvider(TemplateSpec{
+		Name:    "fake",
+		Match:   "^fake-",
+		Command: script + " {{.Prompt}}",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateProvider failed: %v", err)
+	}
+	Register(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	result := Invoke(ctx, InvokeOptions{
+		WorktreePath: t.TempDir(),
+		Task:         "Anything",
+		Model:        "fake-1",
+		Timeout:      30,
+		LogDir:       t.TempDir(),
+	}, "anything")
+
+	if result.Success {
+		t.Fatal("expected a cancelled run to fail")
+	}
+	if !errors.Is(result.Error, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", result.Error)
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	if got := LogPath("logs", "fix-nav", 1, 1); got != filepath.Join("logs", "fix-nav.log") {
+		t.Errorf("single-pass log path = %q", got)
+	}
+	if got := LogPath("logs", "fix-nav", 2, 3); got != filepath.Join("logs", "fix-nav-iter2.log") {
+		t.Errorf("loop log path = %q", got)
+	}
+}
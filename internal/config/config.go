@@ -1,12 +1,11 @@
 package config
 
-import "os"
-
 // Config holds all runtime configuration for a MOCHI run.
 type Config struct {
 	// Input source
 	InputFile   string
 	IssueNumber int
+	RunID       string // run ID; Run generates one when empty, Resume continues this run or the most recent
 
 	// Execution
 	Model         string
@@ -15,7 +14,10 @@ type Config struct {
 	TaskFilter    string
 	DryRun        bool
 	Verbose       bool
+	Dashboard     bool // full-screen task dashboard while agents run (TTY only)
 	KeepWorktrees bool
+	KeepOnCancel  bool // keep worktrees of a cancelled run for 'mochi resume'
+	KeepFailed    bool // keep worktrees of failed and skipped tasks for 'mochi retry'
 	CreatePRs     bool
 	PromptModel   bool // show interactive model picker at startup
 	MaxWorktrees  int  // max concurrent worktrees (0 = unlimited)
@@ -29,34 +31,120 @@ type Config struct {
 	LogDir string
 
 	// Ralph Loop
-	ReviewerModel string // empty = no reviewer / no loop
-	MaxIterations int    // default: 1 (single pass, no loop)
-	OutputMode    string // pr | research-report | audit | knowledge-base | issue | file
-	OutputDir     string // directory for file/report outputs
+	ReviewerModel  string   // empty = no reviewer / no loop
+	ReviewerModels []string // additional reviewers for consensus review
+	ReviewPolicy   string   // unanimous | majority | any-blocking
+	MaxIterations  int      // default: 1 (single pass, no loop)
+	SummaryModel   string   // cheap model that compacts older iterations; empty = no summarization
+	OutputMode     string   // pr | research-report | audit | knowledge-base | issue | file
+	OutputDir      string   // directory for file/report outputs
+	IssueLabels    []string // labels of issues filed in issue output mode
+	IssueAssignees []string // assignees of issues filed in issue output mode
+
+	// Reviewer diff limits
+	ReviewDiffLimit int      // max diff bytes shown to the reviewer
+	ReviewExclude   []string // pathspec patterns left out of the reviewer diff
+
+	// Reviewer verdict format
+	ReviewFormat string   // text (DONE/RETRY line) | json (structured verdict)
+	ReviewRubric []string // criteria scored in json verdicts
+
+	// Learnings carried across runs in .mochi/learnings.json
+	Learnings      bool // collect learnings and inject relevant ones into worker prompts
+	LearningsLimit int  // max learnings injected per task
+
+	// Machine-readable run reports for CI; empty = not written
+	ReportJSON  string
+	ReportJUnit string
+
+	// Lifecycle events: NDJSON log (empty = none) and shell hooks per event type
+	EventLog string
+	Hooks    map[string][]string
+
+	// Verification commands run in each worktree after every worker iteration
+	Verify []string
+
+	// Worktree setup run in each new worktree before its agent starts
+	Setup SetupConfig
 
 	// Workspace
 	Workspace string // ai-native-dev workspace mode: "" (disabled), "zellij", "auto"
+
+	// Providers declared as command templates (see .mochi/config.yaml)
+	Providers []ProviderConfig
 }
 
-// Default returns a Config with sensible defaults.
-// The default model can be overridden via the MOCHI_MODEL environment variable.
-func Default() Config {
-	model := "claude-sonnet-4-6"
-	if env := os.Getenv("MOCHI_MODEL"); env != "" {
-		model = env
+// ProviderConfig declares an agent provider as a command template. Models whose
+// name matches Match are run with Command, rendered with .Model, .Prompt and
+// .PromptFile.
+type ProviderConfig struct {
+	Name           string `yaml:"name"`
+	Match          string `yaml:"match"`
+	Command        string `yaml:"command"`
+	SuccessPattern string `yaml:"success_pattern"`
+	OutputPattern  string `yaml:"output_pattern"`
+}
+
+// SetupConfig prepares each new worktree before its agent runs. Copy and Link
+// list paths or globs in the main checkout (e.g. untracked .env files) that
+// are copied or symlinked into the worktree; Commands then run inside it, in
+// order.
+type SetupConfig struct {
+	Copy     []string       `yaml:"copy"`
+	Link     []string       `yaml:"link"`
+	Commands []SetupCommand `yaml:"commands"`
+}
+
+// SetupCommand is one setup command. When Outputs is set, the listed paths are
+// cached after a successful run, keyed by the command and the contents of the
+// files matching Key, and later worktrees with the same key reuse them instead
+// of running the command. In a config file a plain string is shorthand for a
+// command without caching.
+type SetupCommand struct {
+	Run     string   `yaml:"run"`
+	Outputs []string `yaml:"outputs"`
+	Key     []string `yaml:"key"`
+}
+
+// Reviewers returns the reviewer models for a run: ReviewerModel followed by
+// ReviewerModels, without duplicates. It is empty when no reviewer is set.
+func (c Config) Reviewers() []string {
+	var models []string
+	seen := make(map[string]bool)
+	for _, m := range append([]string{c.ReviewerModel}, c.ReviewerModels...) {
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		models = append(models, m)
 	}
+	return models
+}
 
+// Default returns a Config with MOCHI's built-in defaults, before any config
+// file or environment variable is applied. See Load for the layered config.
+func Default() Config {
 	return Config{
-		Model:         model,
+		Model:         "claude-sonnet-4-6",
 		InputFile:     "PRD.md",
 		BaseBranch:    "main",
 		BranchPrefix:  "feature",
 		WorktreeDir:   ".worktrees",
 		LogDir:        "logs",
-		Timeout:       300000000,
+		Timeout:       3000,
 		MaxIterations: 1,
+		KeepOnCancel:  true,
 		MaxWorktrees:  0,
 		OutputMode:    "pr",
 		OutputDir:     "output",
+
+		ReviewDiffLimit: 20000,
+		ReviewExclude:   []string{"go.sum", "*.lock", "package-lock.json", "pnpm-lock.yaml"},
+		ReviewPolicy:    "unanimous",
+		ReviewFormat:    "text",
+		ReviewRubric:    []string{"correctness", "completeness", "tests", "code quality"},
+
+		Learnings:      true,
+		LearningsLimit: 10,
 	}
 }
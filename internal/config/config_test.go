@@ -0,0 +1,248 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFile_Missing(t *testing.T) {
+	f, err := LoadFile(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error for missing file: %v", err)
+	}
+	if len(f.Providers) != 0 {
+		t.Errorf("expected empty File, got %+v", f)
+	}
+}
+
+func TestLoadFile_Providers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `providers:
+  - name: mycli
+    match: '^mycli-'
+    command: mycli --model {{.Model}} --prompt-file {{.PromptFile}}
+    success_pattern: 'OK$'
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(f.Providers) != 1 {
+		t.Fatalf("got %d providers; want 1", len(f.Providers))
+	}
+	p := f.Providers[0]
+	if p.Name != "mycli" || p.Match != "^mycli-" || p.SuccessPattern != "OK$" {
+		t.Errorf("provider = %+v", p)
+	}
+	if p.Command != "mycli --model {{.Model}} --prompt-file {{.PromptFile}}" {
+		t.Errorf("Command = %q", p.Command)
+	}
+
+	cfg := Default()
+	f.Apply(&cfg)
+	if len(cfg.Providers) != 1 {
+		t.Errorf("Apply did not copy providers")
+	}
+}
+
+func TestLoadFile_Setup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `setup:
+  copy: [.env]
+  commands:
+    - go generate ./...
+    - run: npm ci
+      outputs: [node_modules]
+      key: [package-lock.json]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	cfg := Default()
+	f.Apply(&cfg)
+	cmds := cfg.Setup.Commands
+	if len(cfg.Setup.Copy) != 1 || len(cmds) != 2 {
+		t.Fatalf("setup = %+v", cfg.Setup)
+	}
+	if cmds[0].Run != "go generate ./..." || len(cmds[0].Outputs) != 0 {
+		t.Errorf("shorthand command = %+v", cmds[0])
+	}
+	if cmds[1].Run != "npm ci" || cmds[1].Outputs[0] != "node_modules" || cmds[1].Key[0] != "package-lock.json" {
+		t.Errorf("cached command = %+v", cmds[1])
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("providers: [unclosed"), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected parse error, got nil")
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("cannot create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	userDir := t.TempDir()
+	repoRoot := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("MOCHI_MODEL", "")
+	t.Setenv("MOCHI_LOG_DIR", "env-logs")
+	t.Setenv("MOCHI_KEEP_WORKTREES", "true")
+
+	writeConfig(t, filepath.Join(userDir, "mochi", "config.yaml"), `model: user-model
+branch_prefix: user-prefix
+worktree_dir: user-worktrees
+log_dir: user-logs
+`)
+	writeConfig(t, filepath.Join(repoRoot, RepoFile), `branch_prefix: repo-prefix
+log_dir: repo-logs
+max_iterations: 3
+verify:
+  - go test ./...
+`)
+
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"Model (user over default)", cfg.Model, "user-model"},
+		{"WorktreeDir (user)", cfg.WorktreeDir, "user-worktrees"},
+		{"BranchPrefix (repo over user)", cfg.BranchPrefix, "repo-prefix"},
+		{"LogDir (env over repo)", cfg.LogDir, "env-logs"},
+		{"MaxIterations (repo)", cfg.MaxIterations, 3},
+		{"KeepWorktrees (env)", cfg.KeepWorktrees, true},
+		{"BaseBranch (default)", cfg.BaseBranch, "main"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v; want %v", c.name, c.got, c.want)
+		}
+	}
+	if len(cfg.Verify) != 1 || cfg.Verify[0] != "go test ./..." {
+		t.Errorf("Verify = %q; want [go test ./...]", cfg.Verify)
+	}
+}
+
+func TestLoad_InvalidEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("MOCHI_MAX_ITERATIONS", "lots")
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected error for non-integer MOCHI_MAX_ITERATIONS")
+	}
+}
+
+func TestFileApply_ZeroValuesOverride(t *testing.T) {
+	zero := 0
+	no := false
+	empty := ""
+	cfg := Default()
+	cfg.MaxWorktrees = 4
+	cfg.CreatePRs = true
+	cfg.ReviewerModel = "claude-opus-4-6"
+
+	File{MaxWorktrees: &zero, CreatePRs: &no, ReviewerModel: &empty}.Apply(&cfg)
+
+	if cfg.MaxWorktrees != 0 || cfg.CreatePRs || cfg.ReviewerModel != "" {
+		t.Errorf("explicit zero values should override: %+v", cfg)
+	}
+}
+
+func TestLoadFile_EmptyReviewExcludeClearsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("review_exclude: []\n"), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	cfg := Default()
+	f.Apply(&cfg)
+	if len(cfg.ReviewExclude) != 0 {
+		t.Errorf("ReviewExclude = %v; want none", cfg.ReviewExclude)
+	}
+
+	cfg = Default()
+	File{}.Apply(&cfg)
+	if len(cfg.ReviewExclude) == 0 {
+		t.Error("an absent review_exclude key should keep the defaults")
+	}
+}
+
+// The example documents the defaults: copying it must not change behaviour.
+func TestLoadFile_ExampleMatchesDefaults(t *testing.T) {
+	f, err := LoadFile(filepath.Join("..", "..", "config", "config.example.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	var cfg Config
+	f.Apply(&cfg)
+	want := Default()
+	got, def := reflect.ValueOf(cfg), reflect.ValueOf(want)
+	for i := 0; i < got.NumField(); i++ {
+		if !sameValue(got.Field(i), def.Field(i)) {
+			t.Errorf("%s: example = %+v; default = %+v", got.Type().Field(i).Name, got.Field(i), def.Field(i))
+		}
+	}
+}
+
+// sameValue is reflect.DeepEqual, except that an empty slice or map equals
+// a nil one.
+func sameValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !sameValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func TestReviewers(t *testing.T) {
+	cfg := Default()
+	if len(cfg.Reviewers()) != 0 {
+		t.Errorf("default config should have no reviewers, got %v", cfg.Reviewers())
+	}
+	cfg.ReviewerModel = "claude-opus-4-6"
+	cfg.ReviewerModels = []string{"gemini-2.5-pro", "claude-opus-4-6", ""}
+	got := cfg.Reviewers()
+	if len(got) != 2 || got[0] != "claude-opus-4-6" || got[1] != "gemini-2.5-pro" {
+		t.Errorf("Reviewers() = %v", got)
+	}
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFile is the repository-level config file, relative to the repo root.
+const RepoFile = ".mochi/config.yaml"
+
+// File mirrors the contents of a MOCHI config file. Scalar fields are pointers
+// so that a key left out of the file does not override a lower layer.
+//
+// Per-invocation settings (issue number, task filter, dry run) are flags only.
+type File struct {
+	InputFile     *string `yaml:"input"`
+	Model         *string `yaml:"model"`
+	Timeout       *int    `yaml:"timeout"`
+	Sequential    *bool   `yaml:"sequential"`
+	Verbose       *bool   `yaml:"verbose"`
+	Dashboard     *bool   `yaml:"dashboard"`
+	KeepOnCancel  *bool   `yaml:"keep_on_cancel"`
+	KeepFailed    *bool   `yaml:"keep_failed"`
+	KeepWorktrees *bool   `yaml:"keep_worktrees"`
+	CreatePRs     *bool   `yaml:"create_prs"`
+	PromptModel   *bool   `yaml:"prompt_model"`
+	MaxWorktrees  *int    `yaml:"worktrees"`
+
+	BaseBranch   *string `yaml:"base_branch"`
+	BranchPrefix *string `yaml:"branch_prefix"`
+	WorktreeDir  *string `yaml:"worktree_dir"`
+	LogDir       *string `yaml:"log_dir"`
+
+	ReviewerModel  *string  `yaml:"reviewer_model"`
+	ReviewerModels []string `yaml:"reviewer_models"`
+	ReviewPolicy   *string  `yaml:"review_policy"`
+	MaxIterations  *int     `yaml:"max_iterations"`
+	SummaryModel   *string  `yaml:"summary_model"`
+	OutputMode     *string  `yaml:"output_mode"`
+	OutputDir      *string  `yaml:"output_dir"`
+	IssueLabels    []string `yaml:"issue_labels"`
+	IssueAssignees []string `yaml:"issue_assignees"`
+
+	ReviewDiffLimit *int     `yaml:"review_diff_limit"`
+	ReviewExclude   []string `yaml:"review_exclude"`
+	ReviewFormat    *string  `yaml:"review_format"`
+	ReviewRubric    []string `yaml:"review_rubric"`
+
+	Learnings      *bool `yaml:"learnings"`
+	LearningsLimit *int  `yaml:"learnings_limit"`
+
+	ReportJSON  *string `yaml:"report_json"`
+	ReportJUnit *string `yaml:"report_junit"`
+
+	EventLog *string             `yaml:"event_log"`
+	Hooks    map[string][]string `yaml:"hooks"`
+
+	Workspace *string `yaml:"workspace"`
+
+	Verify    []string         `yaml:"verify"`
+	Setup     *SetupConfig     `yaml:"setup"`
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+// Load builds the effective config for a run in repoRoot by layering, from
+// lowest to highest precedence:
+//
+//	built-in defaults < user file < repo file < MOCHI_* environment variables
+//
+// Command-line flags take precedence over all of these; the CLI uses the
+// result of Load as its flag defaults.
+func Load(repoRoot string) (Config, error) {
+	cfg := Default()
+
+	if path := UserFile(); path != "" {
+		f, err := LoadFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		f.Apply(&cfg)
+	}
+
+	repo, err := LoadFile(filepath.Join(repoRoot, RepoFile))
+	if err != nil {
+		return cfg, err
+	}
+	repo.Apply(&cfg)
+
+	env, err := envFile(os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+	env.Apply(&cfg)
+
+	return cfg, nil
+}
+
+// UserFile returns the path of the user-level config file,
+// $XDG_CONFIG_HOME/mochi/config.yaml or ~/.config/mochi/config.yaml.
+// It returns "" when no home directory can be determined.
+func UserFile() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "mochi", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "mochi", "config.yaml")
+}
+
+// LoadFile reads a config file. A missing file is not an error and yields an
+// empty File.
+func LoadFile(path string) (File, error) {
+	var f File
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("cannot read config %q: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("cannot parse config %q: %w", path, err)
+	}
+	return f, nil
+}
+
+// Apply copies the values set in f onto cfg.
+func (f File) Apply(cfg *Config) {
+	setString(&cfg.InputFile, f.InputFile)
+	setString(&cfg.Model, f.Model)
+	setInt(&cfg.Timeout, f.Timeout)
+	setBool(&cfg.Sequential, f.Sequential)
+	setBool(&cfg.Verbose, f.Verbose)
+	setBool(&cfg.Dashboard, f.Dashboard)
+	setBool(&cfg.KeepOnCancel, f.KeepOnCancel)
+	setBool(&cfg.KeepFailed, f.KeepFailed)
+	setBool(&cfg.KeepWorktrees, f.KeepWorktrees)
+	setBool(&cfg.CreatePRs, f.CreatePRs)
+	setBool(&cfg.PromptModel, f.PromptModel)
+	setInt(&cfg.MaxWorktrees, f.MaxWorktrees)
+
+	setString(&cfg.BaseBranch, f.BaseBranch)
+	setString(&cfg.BranchPrefix, f.BranchPrefix)
+	setString(&cfg.WorktreeDir, f.WorktreeDir)
+	setString(&cfg.LogDir, f.LogDir)
+
+	setString(&cfg.ReviewerModel, f.ReviewerModel)
+	if len(f.ReviewerModels) > 0 {
+		cfg.ReviewerModels = f.ReviewerModels
+	}
+	setString(&cfg.ReviewPolicy, f.ReviewPolicy)
+	setInt(&cfg.MaxIterations, f.MaxIterations)
+	setString(&cfg.SummaryModel, f.SummaryModel)
+	setString(&cfg.OutputMode, f.OutputMode)
+	setString(&cfg.OutputDir, f.OutputDir)
+	if len(f.IssueLabels) > 0 {
+		cfg.IssueLabels = f.IssueLabels
+	}
+	if len(f.IssueAssignees) > 0 {
+		cfg.IssueAssignees = f.IssueAssignees
+	}
+	setInt(&cfg.ReviewDiffLimit, f.ReviewDiffLimit)
+	// An explicit empty review_exclude list turns the default exclusions off.
+	if f.ReviewExclude != nil {
+		cfg.ReviewExclude = f.ReviewExclude
+	}
+	setString(&cfg.ReviewFormat, f.ReviewFormat)
+	if len(f.ReviewRubric) > 0 {
+		cfg.ReviewRubric = f.ReviewRubric
+	}
+	setBool(&cfg.Learnings, f.Learnings)
+	setInt(&cfg.LearningsLimit, f.LearningsLimit)
+
+	setString(&cfg.ReportJSON, f.ReportJSON)
+	setString(&cfg.ReportJUnit, f.ReportJUnit)
+	setString(&cfg.EventLog, f.EventLog)
+	if len(f.Hooks) > 0 {
+		cfg.Hooks = f.Hooks
+	}
+
+	setString(&cfg.Workspace, f.Workspace)
+
+	if len(f.Verify) > 0 {
+		cfg.Verify = f.Verify
+	}
+	if f.Setup != nil {
+		cfg.Setup = *f.Setup
+	}
+	if len(f.Providers) > 0 {
+		cfg.Providers = f.Providers
+	}
+}
+
+// UnmarshalYAML accepts a plain string as a setup command without caching.
+func (c *SetupCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Run = node.Value
+		return nil
+	}
+	type plain SetupCommand // without this method, to avoid recursion
+	return node.Decode((*plain)(c))
+}
+
+// envFile reads the MOCHI_* environment variables into a File. Each scalar key
+// maps to MOCHI_<KEY>, e.g. branch_prefix → MOCHI_BRANCH_PREFIX. List and map
+// values (verify, reviewer_models, review_exclude, review_rubric, issue_labels,
+// issue_assignees, hooks, setup, providers) can only be set in files or flags.
+func envFile(lookup func(string) (string, bool)) (File, error) {
+	var f File
+	var err error
+
+	str := func(key string, dst **string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = &v
+		}
+	}
+	num := func(key string, dst **int) {
+		v, ok := lookup(key)
+		if !ok || v == "" || err != nil {
+			return
+		}
+		n, convErr := strconv.Atoi(v)
+		if convErr != nil {
+			err = fmt.Errorf("invalid %s=%q: expected an integer", key, v)
+			return
+		}
+		*dst = &n
+	}
+	flag := func(key string, dst **bool) {
+		v, ok := lookup(key)
+		if !ok || v == "" || err != nil {
+			return
+		}
+		b, convErr := strconv.ParseBool(v)
+		if convErr != nil {
+			err = fmt.Errorf("invalid %s=%q: expected true or false", key, v)
+			return
+		}
+		*dst = &b
+	}
+
+	str("MOCHI_INPUT", &f.InputFile)
+	str("MOCHI_MODEL", &f.Model)
+	num("MOCHI_TIMEOUT", &f.Timeout)
+	flag("MOCHI_SEQUENTIAL", &f.Sequential)
+	flag("MOCHI_VERBOSE", &f.Verbose)
+	flag("MOCHI_DASHBOARD", &f.Dashboard)
+	flag("MOCHI_KEEP_ON_CANCEL", &f.KeepOnCancel)
+	flag("MOCHI_KEEP_FAILED", &f.KeepFailed)
+	flag("MOCHI_KEEP_WORKTREES", &f.KeepWorktrees)
+	flag("MOCHI_CREATE_PRS", &f.CreatePRs)
+	flag("MOCHI_PROMPT_MODEL", &f.PromptModel)
+	num("MOCHI_WORKTREES", &f.MaxWorktrees)
+
+	str("MOCHI_BASE_BRANCH", &f.BaseBranch)
+	str("MOCHI_BRANCH_PREFIX", &f.BranchPrefix)
+	str("MOCHI_WORKTREE_DIR", &f.WorktreeDir)
+	str("MOCHI_LOG_DIR", &f.LogDir)
+
+	str("MOCHI_REVIEWER_MODEL", &f.ReviewerModel)
+	str("MOCHI_REVIEW_POLICY", &f.ReviewPolicy)
+	num("MOCHI_REVIEW_DIFF_LIMIT", &f.ReviewDiffLimit)
+	str("MOCHI_REVIEW_FORMAT", &f.ReviewFormat)
+	num("MOCHI_MAX_ITERATIONS", &f.MaxIterations)
+	str("MOCHI_SUMMARY_MODEL", &f.SummaryModel)
+	str("MOCHI_OUTPUT_MODE", &f.OutputMode)
+	str("MOCHI_OUTPUT_DIR", &f.OutputDir)
+	flag("MOCHI_LEARNINGS", &f.Learnings)
+	num("MOCHI_LEARNINGS_LIMIT", &f.LearningsLimit)
+
+	str("MOCHI_REPORT_JSON", &f.ReportJSON)
+	str("MOCHI_REPORT_JUNIT", &f.ReportJUnit)
+	str("MOCHI_EVENT_LOG", &f.EventLog)
+
+	str("MOCHI_WORKSPACE", &f.Workspace)
+
+	return f, err
+}
+
+func setString(dst *string, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setBool(dst *bool, v *bool) {
+	if v != nil {
+		*dst = *v
+	}
+}
@@ -0,0 +1,97 @@
+// Package event defines the lifecycle events of a run and the bus that
+// delivers them to embedders, the NDJSON event log and repo-configured hooks.
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies what happened.
+type Type string
+
+const (
+	RunStarted        Type = "run_started"
+	WorktreeCreated   Type = "worktree_created"
+	TaskQueued        Type = "task_queued"
+	TaskStarted       Type = "task_started"
+	IterationStarted  Type = "iteration_started"
+	VerificationDone  Type = "verification_finished"
+	ReviewVerdict     Type = "review_verdict"
+	IterationFinished Type = "iteration_finished"
+	TaskFinished      Type = "task_finished"
+	OutputWritten     Type = "output_written"
+	PRCreated         Type = "pr_created"
+	WorktreeRemoved   Type = "worktree_removed"
+	RunFinished       Type = "run_finished"
+)
+
+// Types lists every event type in the order a task goes through them.
+var Types = []Type{
+	RunStarted, WorktreeCreated, TaskQueued, TaskStarted, IterationStarted,
+	VerificationDone, ReviewVerdict, IterationFinished, TaskFinished,
+	OutputWritten, PRCreated, WorktreeRemoved, RunFinished,
+}
+
+// Valid reports whether t is a known event type.
+func Valid(t Type) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is one lifecycle event of a run. Fields that do not apply to an
+// event's Type are left empty:
+//
+//   - Status is the task status (running, done, failed, skipped, cancelled)
+//     for task events, passed or failed for verification_finished, done or
+//     retry for review_verdict, and the worker outcome for iteration_finished.
+//   - Message holds errors, failing verification commands, reviewer feedback
+//     and run summaries.
+//   - Path is the worktree of worktree events and the file written by
+//     output_written; URL is the pull request or issue.
+type Event struct {
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	Task      string    `json:"task,omitempty"` // task slug
+	Status    string    `json:"status,omitempty"`
+	Iteration int       `json:"iteration,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Bus delivers every published event to its subscribers, one event at a time
+// and in publishing order. A nil *Bus discards events.
+type Bus struct {
+	mu       sync.Mutex
+	handlers []func(Event)
+}
+
+// Subscribe registers h to receive every event published after the call.
+// Handlers run on the publisher's goroutine and should return quickly.
+func (b *Bus) Subscribe(h func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish stamps e with the current time, unless already set, and hands it to
+// every subscriber.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
@@ -0,0 +1,128 @@
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBus_DeliversInOrder(t *testing.T) {
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: RunStarted}) // must not panic
+
+	bus := &Bus{}
+	var a, b []Type
+	bus.Subscribe(func(e Event) { a = append(a, e.Type) })
+	bus.Subscribe(func(e Event) {
+		if e.Time.IsZero() {
+			t.Error("event was not stamped")
+		}
+		b = append(b, e.Type)
+	})
+	bus.Publish(Event{Type: TaskStarted})
+	bus.Publish(Event{Type: TaskFinished})
+	if len(a) != 2 || a[1] != TaskFinished || len(b) != 2 {
+		t.Errorf("handlers got %v and %v", a, b)
+	}
+}
+
+func TestLog_AppendsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "events.ndjson")
+	for _, typ := range []Type{RunStarted, RunFinished} {
+		log, err := OpenLog(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log.Handle(Event{Type: typ, Task: "add-auth"})
+		if err := log.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	var got []Type
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		got = append(got, e.Type)
+	}
+	if len(got) != 2 || got[0] != RunStarted || got[1] != RunFinished {
+		t.Errorf("log holds %v; want both runs appended", got)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	if _, err := NewHooks(t.TempDir(), map[string][]string{"task_exploded": {"true"}}, nil); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+
+	dir := t.TempDir()
+	var failures []error
+	hooks, err := NewHooks(dir, map[string][]string{
+		"pr_created":    {`echo "$MOCHI_EVENT $MOCHI_EVENT_TASK $MOCHI_EVENT_URL" >> hook.txt`, "cat >> payload.json"},
+		"task_finished": {"exit 3"},
+	}, func(err error) { failures = append(failures, err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks.Handle(Event{Type: PRCreated, Task: "add-auth", URL: "https://example.com/pr/1"})
+	hooks.Handle(Event{Type: TaskFinished, Task: "add-auth"})
+	hooks.Handle(Event{Type: RunFinished}) // no hook configured
+	hooks.Close()
+
+	out, _ := os.ReadFile(filepath.Join(dir, "hook.txt"))
+	if strings.TrimSpace(string(out)) != "pr_created add-auth https://example.com/pr/1" {
+		t.Errorf("hook saw %q", out)
+	}
+	var payload Event
+	data, _ := os.ReadFile(filepath.Join(dir, "payload.json"))
+	if err := json.Unmarshal(data, &payload); err != nil || payload.URL != "https://example.com/pr/1" {
+		t.Errorf("stdin payload = %s (%v)", data, err)
+	}
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "exit 3") {
+		t.Errorf("failures = %v", failures)
+	}
+}
+
+func TestHooks_SlowHookDoesNotBlockPublish(t *testing.T) {
+	dir := t.TempDir()
+	hooks, err := NewHooks(dir, map[string][]string{
+		"iteration_started": {"while [ ! -f release ]; do sleep 0.05; done; echo x >> ran.txt"},
+	}, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := &Bus{}
+	bus.Subscribe(hooks.Handle)
+
+	// More events than any fixed buffer would hold, while the first hook
+	// is still running.
+	const n = 300
+	published := make(chan struct{})
+	go func() {
+		for i := 1; i <= n; i++ {
+			bus.Publish(Event{Type: IterationStarted, Iteration: i})
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a running hook")
+	}
+
+	os.WriteFile(filepath.Join(dir, "release"), nil, 0644)
+	hooks.Close()
+	out, _ := os.ReadFile(filepath.Join(dir, "ran.txt"))
+	if got := strings.Count(string(out), "x"); got != n {
+		t.Errorf("hook ran %d times; want %d", got, n)
+	}
+}
@@ -0,0 +1,160 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HookTimeout bounds how long a single hook command may run.
+const HookTimeout = 60 * time.Second
+
+// Hooks runs shell commands configured per event type. Commands run one at a
+// time in the background, in event order. The queue is unbounded, so a slow
+// hook never holds up the run; Close waits for it to drain.
+type Hooks struct {
+	dir      string
+	commands map[Type][]string
+	onError  func(error)
+
+	mu     sync.Mutex
+	cond   *sync.Cond // signalled when queue grows or closed is set
+	queue  []Event
+	closed bool
+	done   chan struct{}
+}
+
+// NewHooks returns a runner for commands, keyed by event type, that runs them
+// in dir. Failing commands are passed to onError. An unknown event type is an
+// error.
+func NewHooks(dir string, commands map[string][]string, onError func(error)) (*Hooks, error) {
+	h := &Hooks{
+		dir:      dir,
+		commands: make(map[Type][]string, len(commands)),
+		onError:  onError,
+		done:     make(chan struct{}),
+	}
+	h.cond = sync.NewCond(&h.mu)
+	for name, cmds := range commands {
+		if !Valid(Type(name)) {
+			return nil, fmt.Errorf("hooks: unknown event %q", name)
+		}
+		h.commands[Type(name)] = cmds
+	}
+	go h.loop()
+	return h, nil
+}
+
+// Handle queues the hooks configured for e's type. It never blocks on a
+// running hook.
+func (h *Hooks) Handle(e Event) {
+	if len(h.commands[e.Type]) == 0 {
+		return
+	}
+	h.mu.Lock()
+	if !h.closed {
+		h.queue = append(h.queue, e)
+	}
+	h.mu.Unlock()
+	h.cond.Signal()
+}
+
+// Close waits for every queued hook to finish. Events handled after Close
+// are dropped.
+func (h *Hooks) Close() {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	h.cond.Signal()
+	<-h.done
+}
+
+// next blocks until an event is queued and returns it, or returns false once
+// Close was called and the queue is empty.
+func (h *Hooks) next() (Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.queue) == 0 && !h.closed {
+		h.cond.Wait()
+	}
+	if len(h.queue) == 0 {
+		return Event{}, false
+	}
+	e := h.queue[0]
+	h.queue = h.queue[1:]
+	return e, true
+}
+
+func (h *Hooks) loop() {
+	defer close(h.done)
+	for {
+		e, ok := h.next()
+		if !ok {
+			return
+		}
+		for _, command := range h.commands[e.Type] {
+			if err := h.run(command, e); err != nil {
+				h.onError(fmt.Errorf("hook %q for %s failed: %w", command, e.Type, err))
+			}
+		}
+	}
+}
+
+// run executes command through the shell with the event as JSON on stdin and
+// its fields in MOCHI_EVENT_* environment variables.
+func (h *Hooks) run(command string, e Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), HookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = h.dir
+	cmd.Env = append(os.Environ(), env(e)...)
+	payload, _ := json.Marshal(e)
+	cmd.Stdin = bytes.NewReader(payload)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func env(e Event) []string {
+	vars := []string{
+		"MOCHI_EVENT=" + string(e.Type),
+		"MOCHI_EVENT_TIME=" + e.Time.Format(time.RFC3339),
+	}
+	add := func(key, value string) {
+		if value != "" {
+			vars = append(vars, key+"="+value)
+		}
+	}
+	add("MOCHI_EVENT_TASK", e.Task)
+	add("MOCHI_EVENT_STATUS", e.Status)
+	if e.Iteration > 0 {
+		add("MOCHI_EVENT_ITERATION", strconv.Itoa(e.Iteration))
+	}
+	add("MOCHI_EVENT_MODEL", e.Model)
+	add("MOCHI_EVENT_PATH", e.Path)
+	add("MOCHI_EVENT_URL", e.URL)
+	add("MOCHI_EVENT_MESSAGE", e.Message)
+	return vars
+}
@@ -0,0 +1,45 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Log appends events to a file as newline-delimited JSON, one object per line.
+type Log struct {
+	f   *os.File
+	enc *json.Encoder
+	err error
+}
+
+// OpenLog opens the event log at path for appending, creating it and its
+// directory if needed.
+func OpenLog(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("event: cannot create log dir for %q: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("event: cannot open log %q: %w", path, err)
+	}
+	return &Log{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Handle writes e to the log. After the first write error the log stops
+// writing; Close reports the error.
+func (l *Log) Handle(e Event) {
+	if l.err == nil {
+		l.err = l.enc.Encode(e)
+	}
+}
+
+// Close closes the log file and returns the first write error, if any.
+func (l *Log) Close() error {
+	closeErr := l.f.Close()
+	if l.err != nil {
+		return fmt.Errorf("event: cannot write log %q: %w", l.f.Name(), l.err)
+	}
+	return closeErr
+}
@@ -2,6 +2,7 @@ package github
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,9 +13,16 @@ import (
 type PROptions struct {
 	Slug     string
 	Branch   string
+	Base     string // optional PR base branch; empty uses the repo default
 	Task     string
 	LogPath  string
 	RepoRoot string
+
+	// Review is the reviewer's final verdict in markdown, shown in the PR body.
+	Review string
+
+	// Body replaces the description built by BuildPRBody when set.
+	Body string
 }
 
 // PushBranch pushes a branch to the origin remote and sets its upstream.
@@ -30,14 +38,22 @@ func PushBranch(repoRoot, branch string) error {
 
 // CreatePR opens a GitHub pull request via the gh CLI and returns the PR URL.
 func CreatePR(opts PROptions) (string, error) {
-	body := buildPRBody(opts)
+	body := opts.Body
+	if body == "" {
+		body = BuildPRBody(opts)
+	}
 
-	cmd := exec.Command("gh", "pr", "create",
+	args := []string{"pr", "create",
 		"--title", opts.Task,
 		"--body", body,
 		"--label", "mochi-generated",
 		"--head", opts.Branch,
-	)
+	}
+	if opts.Base != "" {
+		args = append(args, "--base", opts.Base)
+	}
+
+	cmd := exec.Command("gh", args...)
 	cmd.Dir = opts.RepoRoot
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -76,8 +92,101 @@ func FetchIssueTasks(issueNumber int, repoRoot string) (string, error) {
 	return tmpFile.Name(), nil
 }
 
-// buildPRBody constructs a markdown PR description from the task and agent log.
-func buildPRBody(opts PROptions) string {
+// IssueOptions holds the data needed to file a GitHub issue.
+type IssueOptions struct {
+	Slug      string // task slug, recorded in the body to recognize the issue on re-runs
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees []string
+	RepoRoot  string
+}
+
+// Issue is an open GitHub issue as returned by gh issue list.
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Body   string `json:"body"`
+}
+
+// IssueMarker is the hidden comment CreateIssue puts in an issue body to tie
+// it to a task.
+func IssueMarker(slug string) string {
+	return fmt.Sprintf("<!-- mochi-task: %s -->", slug)
+}
+
+// FindOpenIssue returns the open issue previously filed for the task: one
+// whose body carries the task's marker or, failing that, whose title matches.
+func FindOpenIssue(repoRoot, slug, title string) (Issue, bool, error) {
+	cmd := exec.Command("gh", "issue", "list",
+		"--state", "open",
+		"--limit", "500",
+		"--json", "number,title,url,body",
+	)
+	cmd.Dir = repoRoot
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Issue{}, false, fmt.Errorf("gh issue list failed: %w\n%s", err, stderr.String())
+	}
+	var issues []Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return Issue{}, false, fmt.Errorf("cannot parse gh issue list output: %w", err)
+	}
+	issue, ok := matchIssue(issues, slug, title)
+	return issue, ok, nil
+}
+
+func matchIssue(issues []Issue, slug, title string) (Issue, bool) {
+	marker := IssueMarker(slug)
+	for _, is := range issues {
+		if strings.Contains(is.Body, marker) {
+			return is, true
+		}
+	}
+	for _, is := range issues {
+		if strings.EqualFold(strings.TrimSpace(is.Title), strings.TrimSpace(title)) {
+			return is, true
+		}
+	}
+	return Issue{}, false
+}
+
+// IssueBody returns body as CreateIssue files it: with the task's marker
+// appended so FindOpenIssue can recognize it.
+func IssueBody(body, slug string) string {
+	return body + "\n\n" + IssueMarker(slug) + "\n"
+}
+
+// CreateIssue files a GitHub issue via the gh CLI and returns its URL.
+func CreateIssue(opts IssueOptions) (string, error) {
+	args := []string{"issue", "create",
+		"--title", opts.Title,
+		"--body", IssueBody(opts.Body, opts.Slug),
+	}
+	for _, l := range opts.Labels {
+		args = append(args, "--label", l)
+	}
+	for _, a := range opts.Assignees {
+		args = append(args, "--assignee", a)
+	}
+
+	cmd := exec.Command("gh", args...)
+	cmd.Dir = opts.RepoRoot
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("gh issue create failed for %q: %w\n%s", opts.Slug, err, string(out))
+	}
+
+	// gh prints the issue URL as the last line of output
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// BuildPRBody constructs a markdown PR description from the task and agent log.
+func BuildPRBody(opts PROptions) string {
 	var sb strings.Builder
 
 	sb.WriteString("## Summary\n\n")
@@ -85,6 +194,12 @@ func buildPRBody(opts PROptions) string {
 	sb.WriteString("## Changes\n\n")
 	sb.WriteString("_See commits for full change details._\n\n")
 
+	if opts.Review != "" {
+		sb.WriteString("## Review\n\n")
+		sb.WriteString(opts.Review)
+		sb.WriteString("\n\n")
+	}
+
 	if summary := readLogSummary(opts.LogPath); summary != "" {
 		sb.WriteString("## Agent Log\n\n```\n")
 		sb.WriteString(summary)
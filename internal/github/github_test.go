@@ -15,7 +15,7 @@ func TestBuildPRBody_NoLog(t *testing.T) {
 		LogPath:  "/nonexistent/path.log",
 		RepoRoot: "/tmp",
 	}
-	body := buildPRBody(opts)
+	body := BuildPRBody(opts)
 	if !strings.Contains(body, "Add user authentication") {
 		t.Errorf("PR body does not contain task title")
 	}
@@ -43,7 +43,7 @@ func TestBuildPRBody_WithLog(t *testing.T) {
 		LogPath:  f.Name(),
 		RepoRoot: "/tmp",
 	}
-	body := buildPRBody(opts)
+	body := BuildPRBody(opts)
 	if !strings.Contains(body, "## Agent Log") {
 		t.Errorf("PR body should contain Agent Log section when log file exists")
 	}
@@ -101,3 +101,32 @@ func TestReadLogSummary_TruncatesAt20(t *testing.T) {
 		t.Errorf("result should contain last line (line 25)")
 	}
 }
+
+func TestBuildPRBody_WithReview(t *testing.T) {
+	opts := PROptions{
+		Slug:    "fix-bug",
+		Task:    "Fix the bug",
+		LogPath: "/nonexistent/path.log",
+		Review:  "**Summary:** Looks good.",
+	}
+	body := BuildPRBody(opts)
+	if !strings.Contains(body, "## Review\n\n**Summary:** Looks good.") {
+		t.Errorf("PR body should contain the review section, got:\n%s", body)
+	}
+}
+
+func TestMatchIssue(t *testing.T) {
+	issues := []Issue{
+		{Number: 1, Title: "Audit auth", Body: "filed by hand"},
+		{Number: 2, Title: "Renamed by a maintainer", Body: "findings\n\n" + IssueMarker("audit-auth")},
+	}
+	if is, ok := matchIssue(issues, "audit-auth", "Audit auth"); !ok || is.Number != 2 {
+		t.Errorf("matchIssue = %v, %v; want the issue carrying the marker", is, ok)
+	}
+	if is, ok := matchIssue(issues, "audit-api", "  audit AUTH "); !ok || is.Number != 1 {
+		t.Errorf("matchIssue = %v, %v; want the title match", is, ok)
+	}
+	if _, ok := matchIssue(issues, "audit-api", "Audit API"); ok {
+		t.Error("matchIssue matched an unrelated issue")
+	}
+}
@@ -0,0 +1,48 @@
+package learnings
+
+import (
+	"regexp"
+	"strings"
+)
+
+// learningLine matches a "LEARNING: ..." line in worker output, optionally
+// bulleted or in bold.
+var learningLine = regexp.MustCompile(`(?m)^\s*(?:[-*]\s+)?(?:\*\*)?LEARNING:(?:\*\*)?\s*(.+)$`)
+
+// bulletLine matches a Markdown list item.
+var bulletLine = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+(.+)$`)
+
+// FromOutput returns the learnings a worker reported in its output, one per
+// "LEARNING:" line.
+func FromOutput(output string) []string {
+	var out []string
+	for _, m := range learningLine.FindAllStringSubmatch(output, -1) {
+		if text := Condense(m[1]); text != "" {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+// FromFeedback splits a reviewer's free-text feedback into separate issues:
+// one per list item, or the whole feedback when it has no list. Headings are
+// ignored.
+func FromFeedback(feedback string) []string {
+	var items []string
+	var prose []string
+	for _, line := range strings.Split(feedback, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if m := bulletLine.FindStringSubmatch(trimmed); m != nil {
+			items = append(items, Condense(m[1]))
+			continue
+		}
+		prose = append(prose, trimmed)
+	}
+	if len(items) == 0 && len(prose) > 0 {
+		items = append(items, Condense(strings.Join(prose, " ")))
+	}
+	return items
+}
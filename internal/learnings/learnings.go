@@ -0,0 +1,366 @@
+// Package learnings keeps a repository-level store of lessons gathered by the
+// Ralph Loop — notes the worker agent reports about the repository and the
+// issues reviewers sent back — so that later runs start with them instead of
+// rediscovering them. The store lives in .mochi/learnings.json at the repo
+// root and outlives the worktrees it was collected in.
+package learnings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// File is the learnings store, relative to the repo root.
+const File = ".mochi/learnings.json"
+
+// Sources of a learning.
+const (
+	SourceAgent    = "agent"    // reported by the worker with a LEARNING: line
+	SourceReviewer = "reviewer" // an issue a reviewer sent back
+)
+
+// maxText bounds the length of a stored learning.
+const maxText = 300
+
+// maxTasks bounds how many task slugs an entry remembers.
+const maxTasks = 5
+
+// similarity is the keyword overlap above which two learnings are considered
+// the same and merged.
+const similarity = 0.7
+
+// Entry is one stored learning.
+type Entry struct {
+	ID       int       `json:"id"`
+	Text     string    `json:"text"`
+	Source   string    `json:"source"`
+	Tasks    []string  `json:"tasks,omitempty"` // slugs of the tasks it came up in, most recent last
+	Count    int       `json:"count"`           // times it was seen
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Store is the learnings of one repository. It is safe for concurrent use.
+// A nil *Store is an empty, disabled store: Add and Save do nothing and
+// Relevant returns nothing.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	entries []Entry
+	now     func() time.Time
+}
+
+// Open loads the store at path. A missing file yields an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, now: time.Now}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read learnings %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("cannot parse learnings %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file.
+func (s *Store) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", filepath.Dir(s.path), err)
+	}
+	// Keep a new store out of the repository's git status. Only done when the
+	// file is first created, so removing the entry to share it sticks.
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		if err := ignore(s.path); err != nil {
+			return fmt.Errorf("cannot write .gitignore: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0644); err != nil {
+		return fmt.Errorf("cannot write learnings: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ignore adds path and its temporary file to the .gitignore next to it,
+// leaving any other patterns in that file alone.
+func ignore(path string) error {
+	gitignore := filepath.Join(filepath.Dir(path), ".gitignore")
+	data, err := os.ReadFile(gitignore)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	existing := strings.Split(string(data), "\n")
+	var add strings.Builder
+	for _, pattern := range []string{"/" + filepath.Base(path), "/" + filepath.Base(path) + ".tmp"} {
+		found := false
+		for _, line := range existing {
+			if strings.TrimSpace(line) == pattern {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add.WriteString(pattern + "\n")
+		}
+	}
+	if add.Len() == 0 {
+		return nil
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	return os.WriteFile(gitignore, append(data, add.String()...), 0644)
+}
+
+// Entries returns a copy of every entry, in ID order.
+func (s *Store) Entries() []Entry {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Add records a learning seen in task. The text is condensed first; if it
+// matches an existing entry closely enough, that entry's count is bumped
+// instead of adding a new one. Add reports whether a new entry was created.
+func (s *Store) Add(source, task, text string) bool {
+	if s == nil {
+		return false
+	}
+	text = Condense(text)
+	if text == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	words := keywords(text)
+	for i := range s.entries {
+		e := &s.entries[i]
+		if overlap(words, keywords(e.Text)) < similarity {
+			continue
+		}
+		e.Count++
+		e.LastSeen = now
+		e.Tasks = addTask(e.Tasks, task)
+		return false
+	}
+
+	id := 1
+	for _, e := range s.entries {
+		id = max(id, e.ID+1)
+	}
+	s.entries = append(s.entries, Entry{
+		ID:       id,
+		Text:     text,
+		Source:   source,
+		Tasks:    addTask(nil, task),
+		Count:    1,
+		Created:  now,
+		LastSeen: now,
+	})
+	return true
+}
+
+// Edit replaces the text of the entry with the given ID.
+func (s *Store) Edit(id int, text string) error {
+	text = Condense(text)
+	if text == "" {
+		return fmt.Errorf("learning text is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID == id {
+			s.entries[i].Text = text
+			return nil
+		}
+	}
+	return fmt.Errorf("no learning with id %d", id)
+}
+
+// Remove deletes the entries with the given IDs and returns how many it found.
+func (s *Store) Remove(ids ...int) int {
+	drop := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	return s.removeIf(func(e Entry) bool { return drop[e.ID] })
+}
+
+// PruneStale deletes the entries last seen before cutoff that were seen fewer
+// than minCount times, and returns how many it removed.
+func (s *Store) PruneStale(cutoff time.Time, minCount int) int {
+	return s.removeIf(func(e Entry) bool {
+		return e.LastSeen.Before(cutoff) && e.Count < minCount
+	})
+}
+
+func (s *Store) removeIf(fn func(Entry) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	removed := 0
+	for _, e := range s.entries {
+		if fn(e) {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.entries = kept
+	return removed
+}
+
+// Relevant returns up to limit learnings for a task, most relevant first.
+// Entries sharing keywords with the task rank highest, weighted by how often
+// they were seen. Agent learnings describe the repository as a whole and are
+// included even without a keyword match; reviewer issues only when they share
+// a keyword with the task.
+func (s *Store) Relevant(task string, limit int) []Entry {
+	if s == nil || limit <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taskWords := keywords(task)
+	type scored struct {
+		Entry
+		score int
+	}
+	var candidates []scored
+	for _, e := range s.entries {
+		shared := 0
+		for w := range keywords(e.Text) {
+			if taskWords[w] {
+				shared++
+			}
+		}
+		if shared == 0 && e.Source != SourceAgent {
+			continue
+		}
+		candidates = append(candidates, scored{e, shared*3 + min(e.Count, 5)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].LastSeen.After(candidates[j].LastSeen)
+	})
+
+	var out []Entry
+	for _, c := range candidates {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, c.Entry)
+	}
+	return out
+}
+
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+// Condense normalizes a learning to a single line: list markers and Markdown
+// emphasis are stripped, whitespace is collapsed, and text beyond maxText
+// characters is cut at a word boundary, or at a character boundary when the
+// text has no space.
+func Condense(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = listMarker.ReplaceAllString(text, "")
+	text = strings.TrimSpace(strings.ReplaceAll(text, "**", ""))
+	end, n := len(text), 0
+	for i := range text {
+		if n == maxText {
+			end = i
+			break
+		}
+		n++
+	}
+	if end == len(text) {
+		return text
+	}
+	cut := strings.LastIndex(text[:end], " ")
+	if cut <= 0 {
+		cut = end
+	}
+	return text[:cut] + "…"
+}
+
+// keywords returns the lower-cased words of text that are long enough to
+// carry meaning.
+func keywords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 3 && !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// overlap is the Jaccard similarity of two keyword sets.
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func addTask(tasks []string, task string) []string {
+	if task == "" {
+		return tasks
+	}
+	for i, t := range tasks {
+		if t == task {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			break
+		}
+	}
+	tasks = append(tasks, task)
+	if len(tasks) > maxTasks {
+		tasks = tasks[len(tasks)-maxTasks:]
+	}
+	return tasks
+}
+
+var stopWords = map[string]bool{
+	"that": true, "this": true, "with": true, "from": true, "have": true,
+	"should": true, "must": true, "when": true, "then": true, "than": true,
+	"into": true, "also": true, "only": true, "were": true, "been": true,
+	"does": true, "will": true, "there": true, "their": true, "they": true,
+	"what": true, "which": true, "make": true, "sure": true, "before": true,
+}
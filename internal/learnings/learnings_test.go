@@ -0,0 +1,175 @@
+package learnings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAdd_MergesNearDuplicates(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "learnings.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !s.Add(SourceAgent, "add-nav", "Run `make generate` before running the Go tests") {
+		t.Fatal("first learning should be new")
+	}
+	if s.Add(SourceAgent, "fix-footer", "- run make generate before running the go tests.") {
+		t.Error("near-duplicate should be merged")
+	}
+	if !s.Add(SourceReviewer, "fix-footer", "Footer links are missing aria labels") {
+		t.Error("unrelated learning should be new")
+	}
+
+	entries := s.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Count != 2 || strings.Join(entries[0].Tasks, ",") != "add-nav,fix-footer" {
+		t.Errorf("merged entry = %+v", entries[0])
+	}
+	if entries[1].ID != 2 {
+		t.Errorf("second entry ID = %d, want 2", entries[1].ID)
+	}
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".mochi", "learnings.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	s.Add(SourceAgent, "add-nav", "Templates live in web/templates")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got := reopened.Entries(); len(got) != 1 || got[0].Text != "Templates live in web/templates" {
+		t.Errorf("reopened entries = %+v", got)
+	}
+}
+
+func TestSave_Gitignore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".mochi")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitignore := filepath.Join(dir, ".gitignore")
+	if err := os.WriteFile(gitignore, []byte("cache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filepath.Join(dir, "learnings.json"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	want := "cache\n/learnings.json\n/learnings.json.tmp\n"
+	if data, _ := os.ReadFile(gitignore); string(data) != want {
+		t.Errorf(".gitignore = %q; want %q", data, want)
+	}
+
+	// Once the store exists, a removed entry stays removed.
+	if err := os.WriteFile(gitignore, []byte("cache\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if data, _ := os.ReadFile(gitignore); string(data) != "cache\n" {
+		t.Errorf(".gitignore = %q after a later save; want it untouched", data)
+	}
+}
+
+func TestCondense(t *testing.T) {
+	long := strings.Repeat("word ", maxText)
+	tests := []struct {
+		name, text, want string
+	}{
+		{"list marker and emphasis", "  - Run **make generate**\n  first ", "Run make generate first"},
+		{"short multibyte", "Übersetzungen liegen in i18n/", "Übersetzungen liegen in i18n/"},
+		{"cut at a word", long, strings.TrimSpace(long[:maxText-1]) + "…"},
+		{"multibyte without spaces", strings.Repeat("é", maxText+10), strings.Repeat("é", maxText) + "…"},
+		{"multibyte at the limit", strings.Repeat("日", maxText), strings.Repeat("日", maxText)},
+	}
+	for _, tt := range tests {
+		got := Condense(tt.text)
+		if got != tt.want {
+			t.Errorf("%s: Condense = %q; want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: Condense split a rune: %q", tt.name, got)
+		}
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "learnings.json"))
+	s.Add(SourceAgent, "a", "Use pnpm, not npm, to install dependencies")
+	s.Add(SourceReviewer, "b", "Navbar dropdown must close on Escape")
+	s.Add(SourceReviewer, "c", "Database migrations need a down step")
+
+	var texts []string
+	for _, e := range s.Relevant("Fix the mobile navbar dropdown", 5) {
+		texts = append(texts, e.Text)
+	}
+	if len(texts) != 2 || texts[0] != "Navbar dropdown must close on Escape" {
+		t.Errorf("Relevant = %q; want the navbar issue first, then the agent learning", texts)
+	}
+
+	if got := s.Relevant("anything", 0); len(got) != 0 {
+		t.Errorf("limit 0 should return nothing, got %+v", got)
+	}
+	var nilStore *Store
+	if got := nilStore.Relevant("Fix navbar", 5); got != nil {
+		t.Errorf("nil store returned %+v", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "learnings.json"))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.Add(SourceAgent, "a", "Old one-off learning about caching")
+	s.Add(SourceAgent, "a", "Old recurring learning about linting")
+	s.Add(SourceAgent, "b", "Old recurring learning about linting")
+	now = now.AddDate(0, 2, 0)
+	s.Add(SourceAgent, "c", "Fresh learning about fixtures")
+
+	if n := s.PruneStale(now.AddDate(0, 0, -30), 2); n != 1 {
+		t.Errorf("PruneStale removed %d, want 1", n)
+	}
+	if n := s.Remove(3, 99); n != 1 {
+		t.Errorf("Remove removed %d, want 1", n)
+	}
+	if got := s.Entries(); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("remaining entries = %+v", got)
+	}
+}
+
+func TestFromOutput(t *testing.T) {
+	out := "Done.\nLEARNING: tests need the DB_URL env var\n- **LEARNING:** lint with `make lint`\nnot a LEARNING: mid-line\n"
+	got := FromOutput(out)
+	if len(got) != 2 || got[0] != "tests need the DB_URL env var" || got[1] != "lint with `make lint`" {
+		t.Errorf("FromOutput = %q", got)
+	}
+}
+
+func TestFromFeedback(t *testing.T) {
+	got := FromFeedback("### Issues\n\n- Missing tests for Close\n2. Docs not updated\n")
+	if len(got) != 2 || got[0] != "Missing tests for Close" || got[1] != "Docs not updated" {
+		t.Errorf("FromFeedback(list) = %q", got)
+	}
+	got = FromFeedback("The navbar does not close.\nPlease fix it.")
+	if len(got) != 1 || got[0] != "The navbar does not close. Please fix it." {
+		t.Errorf("FromFeedback(prose) = %q", got)
+	}
+}
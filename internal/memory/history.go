@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fileHistory = "HISTORY.json"
+
+// recentIterations is how many of the latest iterations are rendered in full;
+// older ones are compacted.
+const recentIterations = 2
+
+// Limits on the rendered history, so a long loop cannot grow the prompt
+// without bound.
+const (
+	maxDigest        = 3000 // bytes of the summarized digest
+	maxRecentOutput  = 1500 // bytes of worker output per recent iteration
+	maxRecentNotes   = 1500 // bytes of reviewer notes per recent iteration
+	maxCompactedLine = 200  // bytes per compacted older iteration
+	maxCompacted     = 10   // compacted older iterations shown; earlier ones are counted
+)
+
+// Iteration is the record of one Ralph Loop iteration.
+type Iteration struct {
+	Iteration     int    `json:"iteration"`
+	Status        string `json:"status"`
+	WorkerOutput  string `json:"worker_output,omitempty"`
+	ReviewerNotes string `json:"reviewer_notes,omitempty"`
+	Verification  string `json:"verification,omitempty"`
+}
+
+// History is every iteration of a task so far. Iterations up to DigestThrough
+// have been summarized into Digest by a model; see Compact.
+type History struct {
+	Digest        string      `json:"digest,omitempty"`
+	DigestThrough int         `json:"digest_through,omitempty"`
+	Iterations    []Iteration `json:"iterations"`
+}
+
+// LoadHistory reads the iteration history of the worktree at worktreePath.
+// A task without history yields an empty History.
+func LoadHistory(worktreePath string) (History, error) {
+	var h History
+	dir, err := Dir(worktreePath)
+	if err != nil {
+		return h, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, fileHistory))
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("memory: cannot read %s: %w", fileHistory, err)
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, fmt.Errorf("memory: cannot parse %s: %w", fileHistory, err)
+	}
+	return h, nil
+}
+
+func saveHistory(dir string, h History) error {
+	out, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, fileHistory), out, 0644); err != nil {
+		return fmt.Errorf("memory: cannot write %s: %w", fileHistory, err)
+	}
+	return nil
+}
+
+// record adds it to h. Re-running an iteration (a resumed or restarted loop)
+// drops it and everything after it first, including a digest that covered it.
+func (h *History) record(it Iteration) {
+	kept := h.Iterations[:0]
+	for _, old := range h.Iterations {
+		if old.Iteration < it.Iteration {
+			kept = append(kept, old)
+		}
+	}
+	h.Iterations = append(kept, it)
+	if h.DigestThrough >= it.Iteration {
+		h.Digest, h.DigestThrough = "", 0
+	}
+}
+
+// older returns the iterations that are neither digested nor recent: the ones
+// Compact would summarize.
+func (h History) older() []Iteration {
+	var out []Iteration
+	cutoff := len(h.Iterations) - recentIterations
+	for i, it := range h.Iterations {
+		if i < cutoff && it.Iteration > h.DigestThrough {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Render formats the history for the worker prompt: the digest, a one-line
+// summary of each older iteration it does not cover, and the most recent
+// iterations in (truncated) full, except for the latest iteration's feedback,
+// which the prompt shows separately.
+func (h History) Render() string {
+	if len(h.Iterations) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if h.Digest != "" {
+		fmt.Fprintf(&b, "### Summary of iterations 1-%d\n\n%s\n\n", h.DigestThrough, truncate(h.Digest, maxDigest))
+	}
+	older := h.older()
+	if n := len(older) - maxCompacted; n > 0 {
+		fmt.Fprintf(&b, "- %d earlier iteration(s) omitted\n", n)
+		older = older[n:]
+	}
+	for _, it := range older {
+		fmt.Fprintf(&b, "- Iteration %d (%s): %s\n", it.Iteration, it.Status, compactLine(it))
+	}
+	start := max(len(h.Iterations)-recentIterations, 0)
+	for i, it := range h.Iterations[start:] {
+		if it.Iteration <= h.DigestThrough {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### Iteration %d (%s)\n", it.Iteration, it.Status)
+		if it.WorkerOutput != "" {
+			fmt.Fprintf(&b, "\nWorker output:\n%s\n", truncate(strings.TrimSpace(it.WorkerOutput), maxRecentOutput))
+		}
+		// The latest iteration's feedback is already in FEEDBACK.md.
+		if start+i == len(h.Iterations)-1 {
+			continue
+		}
+		if it.Verification != "" {
+			fmt.Fprintf(&b, "\nVerification:\n%s\n", truncate(strings.TrimSpace(it.Verification), maxRecentNotes))
+		}
+		if it.ReviewerNotes != "" {
+			fmt.Fprintf(&b, "\nReviewer notes:\n%s\n", truncate(strings.TrimSpace(it.ReviewerNotes), maxRecentNotes))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// compactLine summarizes an iteration in one line without a model: the first
+// line of what sent it back, or of the worker output.
+func compactLine(it Iteration) string {
+	text := it.ReviewerNotes
+	if it.Verification != "" {
+		text = it.Verification
+	}
+	if strings.TrimSpace(text) == "" {
+		text = it.WorkerOutput
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#-* "))
+		if line != "" {
+			return truncate(line, maxCompactedLine)
+		}
+	}
+	return "no output"
+}
+
+const digestPromptTmpl = `You are compacting the history of an AI coding agent's attempts at a task so
+the next attempt knows what already happened. Summarize the iterations below
+in at most 15 short bullet points: what was tried, what the reviewer or the
+verification commands rejected, and what is still open. Keep file names,
+commands and error messages that matter. Output only the bullet points.
+%s
+%s`
+
+// Compact summarizes the iterations that fell out of the recent window into
+// the history's digest, using summarize to run a model on the prompt it is
+// given. It reports whether there was anything to compact.
+func Compact(worktreePath string, summarize func(prompt string) (string, error)) (bool, error) {
+	dir, err := Dir(worktreePath)
+	if err != nil {
+		return false, err
+	}
+	h, err := LoadHistory(worktreePath)
+	if err != nil {
+		return false, err
+	}
+	older := h.older()
+	if len(older) == 0 {
+		return false, nil
+	}
+
+	previous := ""
+	if h.Digest != "" {
+		previous = fmt.Sprintf("\nSummary of iterations 1-%d so far:\n%s\n", h.DigestThrough, h.Digest)
+	}
+	var its strings.Builder
+	for _, it := range older {
+		fmt.Fprintf(&its, "\n=== Iteration %d (%s) ===\n", it.Iteration, it.Status)
+		if it.WorkerOutput != "" {
+			fmt.Fprintf(&its, "Worker output:\n%s\n", truncate(it.WorkerOutput, 4000))
+		}
+		if it.Verification != "" {
+			fmt.Fprintf(&its, "Verification:\n%s\n", it.Verification)
+		}
+		if it.ReviewerNotes != "" {
+			fmt.Fprintf(&its, "Reviewer notes:\n%s\n", it.ReviewerNotes)
+		}
+	}
+
+	digest, err := summarize(fmt.Sprintf(digestPromptTmpl, previous, its.String()))
+	if err != nil {
+		return false, err
+	}
+	digest = strings.TrimSpace(digest)
+	if digest == "" {
+		return false, fmt.Errorf("memory: summary model returned no digest")
+	}
+	h.Digest = truncate(digest, maxDigest)
+	h.DigestThrough = older[len(older)-1].Iteration
+	return true, saveHistory(dir, h)
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func writeIterations(t *testing.T, wt string, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		err := Write(wt, IterationData{
+			Iteration:     i,
+			Task:          "Add nav",
+			WorkerOutput:  fmt.Sprintf("worker output %d", i),
+			ReviewerNotes: fmt.Sprintf("notes %d", i),
+			Status:        "in-progress",
+		})
+		if err != nil {
+			t.Fatalf("Write iteration %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestHistory_RenderKeepsEveryIteration(t *testing.T) {
+	wt := setupWorktree(t)
+	writeIterations(t, wt, 1, 4)
+
+	history := Load(wt).History
+	for _, want := range []string{
+		"- Iteration 1 (in-progress): notes 1",
+		"- Iteration 2 (in-progress): notes 2",
+		"### Iteration 3 (in-progress)",
+		"worker output 3",
+		"notes 3",
+		"worker output 4",
+	} {
+		if !strings.Contains(history, want) {
+			t.Errorf("history missing %q:\n%s", want, history)
+		}
+	}
+	if strings.Contains(history, "notes 4") {
+		t.Errorf("latest feedback should be left to FEEDBACK.md:\n%s", history)
+	}
+}
+
+func TestHistory_RerunDropsLaterIterations(t *testing.T) {
+	wt := setupWorktree(t)
+	writeIterations(t, wt, 1, 3)
+	writeIterations(t, wt, 1, 1)
+
+	h, err := LoadHistory(wt)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(h.Iterations) != 1 || h.Iterations[0].Iteration != 1 {
+		t.Errorf("iterations = %+v; want only the re-run iteration 1", h.Iterations)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	wt := setupWorktree(t)
+	writeIterations(t, wt, 1, 2)
+
+	called := false
+	summarize := func(prompt string) (string, error) {
+		called = true
+		return "- tried X, reviewer wanted Y", nil
+	}
+	if ok, err := Compact(wt, summarize); err != nil || ok || called {
+		t.Fatalf("nothing should be compacted yet: ok=%v err=%v called=%v", ok, err, called)
+	}
+
+	writeIterations(t, wt, 3, 4)
+	var gotPrompt string
+	summarize = func(prompt string) (string, error) {
+		gotPrompt = prompt
+		return "- tried X, reviewer wanted Y", nil
+	}
+	ok, err := Compact(wt, summarize)
+	if err != nil || !ok {
+		t.Fatalf("Compact = %v, %v", ok, err)
+	}
+	if !strings.Contains(gotPrompt, "worker output 1") || !strings.Contains(gotPrompt, "notes 2") || strings.Contains(gotPrompt, "notes 3") {
+		t.Errorf("prompt should cover iterations 1-2 only:\n%s", gotPrompt)
+	}
+
+	history := Load(wt).History
+	if !strings.Contains(history, "### Summary of iterations 1-2\n\n- tried X, reviewer wanted Y") {
+		t.Errorf("history missing digest:\n%s", history)
+	}
+	if strings.Contains(history, "- Iteration 1 (") {
+		t.Errorf("digested iterations should not be listed again:\n%s", history)
+	}
+
+	// The next compaction only sends the new older iteration plus the digest.
+	writeIterations(t, wt, 5, 5)
+	if _, err := Compact(wt, summarize); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if !strings.Contains(gotPrompt, "Summary of iterations 1-2 so far") || strings.Contains(gotPrompt, "worker output 1") || !strings.Contains(gotPrompt, "worker output 3") {
+		t.Errorf("incremental prompt wrong:\n%s", gotPrompt)
+	}
+}
@@ -3,15 +3,23 @@ package memory
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
+// dirName is the directory inside a worktree's git dir that holds its memory
+// files. Living under the git dir keeps them out of the working tree, so they
+// can never be committed or overwrite a tracked file such as a repo's own
+// AGENTS.md, and they are removed together with the worktree.
+const dirName = "mochi"
+
 const (
 	fileProgress = "PROGRESS.md"
 	fileMemory   = "MEMORY.md"
 	fileAgents   = "AGENTS.md"
 	fileFeedback = "FEEDBACK.md"
+	fileReview   = "REVIEW.json"
 )
 
 // Context holds the content of all memory files for a given worktree iteration.
@@ -20,20 +28,43 @@ type Context struct {
 	Memory   string
 	Agents   string
 	Feedback string
+	Review   string // latest structured reviewer verdict as JSON, if any
+	History  string // earlier iterations, compacted; see History.Render
 }
 
 // HasAny returns true if at least one memory file has content.
 func (c Context) HasAny() bool {
-	return c.Progress != "" || c.Memory != "" || c.Agents != "" || c.Feedback != ""
+	return c.Progress != "" || c.Memory != "" || c.Agents != "" || c.Feedback != "" || c.History != ""
+}
+
+// Dir returns the directory holding the memory files of the worktree at
+// worktreePath: <git-dir>/mochi, where <git-dir> is the worktree's private git
+// directory (.git/worktrees/<name> for a linked worktree).
+func Dir(worktreePath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = worktreePath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("memory: cannot resolve git dir of %s: %w", worktreePath, err)
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), dirName), nil
 }
 
-// Load reads all four memory files from worktreePath. Missing files are silently ignored.
+// Load reads all memory files of the worktree at worktreePath. Missing files
+// are silently ignored.
 func Load(worktreePath string) Context {
+	dir, err := Dir(worktreePath)
+	if err != nil {
+		return Context{}
+	}
+	h, _ := LoadHistory(worktreePath)
 	return Context{
-		Progress: readFile(filepath.Join(worktreePath, fileProgress)),
-		Memory:   readFile(filepath.Join(worktreePath, fileMemory)),
-		Agents:   readFile(filepath.Join(worktreePath, fileAgents)),
-		Feedback: readFile(filepath.Join(worktreePath, fileFeedback)),
+		Progress: readFile(filepath.Join(dir, fileProgress)),
+		Memory:   readFile(filepath.Join(dir, fileMemory)),
+		Agents:   readFile(filepath.Join(dir, fileAgents)),
+		Feedback: readFile(filepath.Join(dir, fileFeedback)),
+		Review:   readFile(filepath.Join(dir, fileReview)),
+		History:  h.Render(),
 	}
 }
 
@@ -43,11 +74,24 @@ type IterationData struct {
 	Task          string
 	WorkerOutput  string
 	ReviewerNotes string
+	Verification  string // Markdown summary of failing verification commands
+	Review        string // structured reviewer verdict as JSON; empty for line verdicts
 	Status        string // "in-progress" | "done" | "failed"
 }
 
-// Write persists the four memory files into worktreePath based on IterationData.
+// Write persists the four memory files for the worktree at worktreePath based
+// on IterationData, plus REVIEW.json when the reviewer returned a structured
+// verdict, and appends the iteration to the task's history. Nothing is written
+// inside the working tree itself; see Dir.
 func Write(worktreePath string, data IterationData) error {
+	dir, err := Dir(worktreePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("memory.Write: cannot create %s: %w", dir, err)
+	}
+
 	progress := fmt.Sprintf("# Task Progress\n\n**Task:** %s\n\n**Iteration:** %d\n\n**Status:** %s\n",
 		data.Task, data.Iteration, data.Status)
 
@@ -56,11 +100,7 @@ func Write(worktreePath string, data IterationData) error {
 
 	agents := buildAgentsFile(data)
 
-	feedback := ""
-	if data.ReviewerNotes != "" {
-		feedback = fmt.Sprintf("# Reviewer Feedback\n\n## Iteration %d\n\n%s\n",
-			data.Iteration, data.ReviewerNotes)
-	}
+	feedback := buildFeedbackFile(data)
 
 	files := map[string]string{
 		fileProgress: progress,
@@ -70,14 +110,56 @@ func Write(worktreePath string, data IterationData) error {
 	}
 
 	for name, content := range files {
-		path := filepath.Join(worktreePath, name)
+		path := filepath.Join(dir, name)
 		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 			return fmt.Errorf("memory.Write: cannot write %s: %w", name, err)
 		}
 	}
+
+	// An unreadable history is started afresh rather than failing the loop.
+	h, _ := LoadHistory(worktreePath)
+	h.record(Iteration{
+		Iteration:     data.Iteration,
+		Status:        data.Status,
+		WorkerOutput:  truncate(data.WorkerOutput, 4000),
+		ReviewerNotes: data.ReviewerNotes,
+		Verification:  data.Verification,
+	})
+	if err := saveHistory(dir, h); err != nil {
+		return err
+	}
+
+	reviewPath := filepath.Join(dir, fileReview)
+	if data.Review == "" {
+		if err := os.Remove(reviewPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("memory.Write: cannot remove %s: %w", fileReview, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(reviewPath, []byte(data.Review), 0644); err != nil {
+		return fmt.Errorf("memory.Write: cannot write %s: %w", fileReview, err)
+	}
 	return nil
 }
 
+func buildFeedbackFile(data IterationData) string {
+	if data.ReviewerNotes == "" && data.Verification == "" {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Reviewer Feedback\n\n## Iteration %d\n\n", data.Iteration)
+	if data.Verification != "" {
+		b.WriteString("### Verification Failures\n\n")
+		b.WriteString(data.Verification)
+		b.WriteString("\n")
+	}
+	if data.ReviewerNotes != "" {
+		b.WriteString(data.ReviewerNotes)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 func buildAgentsFile(data IterationData) string {
 	var b strings.Builder
 	b.WriteString("# Agent Learnings\n\n")
@@ -88,9 +170,9 @@ func buildAgentsFile(data IterationData) string {
 		b.WriteString("\n\n")
 	}
 	b.WriteString("## Instructions for Next Iteration\n\n")
-	b.WriteString("- Review FEEDBACK.md before starting work\n")
+	b.WriteString("- Review the reviewer feedback before starting work\n")
 	b.WriteString("- Address all reviewer notes\n")
-	b.WriteString("- Build on previous iteration's progress in MEMORY.md\n")
+	b.WriteString("- Build on the previous iteration's progress\n")
 	return b.String()
 }
 
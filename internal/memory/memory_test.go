@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupWorktree creates a repo with a tracked AGENTS.md and returns the path of
+// a linked worktree on a task branch.
+func setupWorktree(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+		return string(out)
+	}
+	run(repo, "init", "-b", "main")
+	run(repo, "config", "user.email", "test@test.com")
+	run(repo, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(repo, "AGENTS.md"), []byte("# Repo agents\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(repo, "add", ".")
+	run(repo, "commit", "-m", "initial")
+
+	wt := filepath.Join(t.TempDir(), "task")
+	run(repo, "worktree", "add", "-b", "task", wt)
+	return wt
+}
+
+func TestWrite_StaysOutOfWorkingTree(t *testing.T) {
+	wt := setupWorktree(t)
+
+	err := Write(wt, IterationData{
+		Task:          "Add nav",
+		Iteration:     1,
+		WorkerOutput:  "added nav.go",
+		ReviewerNotes: "missing tests",
+		Review:        `{"done": false}`,
+		Status:        "in-progress",
+	})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain", "--ignored")
+	cmd.Dir = wt
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git status: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("memory files leaked into the worktree:\n%s", out)
+	}
+	agents, err := os.ReadFile(filepath.Join(wt, "AGENTS.md"))
+	if err != nil || string(agents) != "# Repo agents\n" {
+		t.Errorf("tracked AGENTS.md was changed: %q, %v", agents, err)
+	}
+
+	ctx := Load(wt)
+	if !strings.Contains(ctx.Feedback, "missing tests") || !strings.Contains(ctx.Memory, "added nav.go") {
+		t.Errorf("Load did not read back the memory: %+v", ctx)
+	}
+	if ctx.Review != `{"done": false}` {
+		t.Errorf("Review = %q", ctx.Review)
+	}
+	if strings.Contains(ctx.Agents, "Repo agents") {
+		t.Error("Load read the repo's AGENTS.md instead of the memory file")
+	}
+}
+
+func TestDir_PerWorktree(t *testing.T) {
+	wt := setupWorktree(t)
+	dir, err := Dir(wt)
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+	if !strings.Contains(filepath.ToSlash(dir), "/.git/worktrees/task/") {
+		t.Errorf("Dir = %s; want it under the worktree's git dir", dir)
+	}
+}
+
+func TestLoad_NotARepo(t *testing.T) {
+	if ctx := Load(t.TempDir()); ctx.HasAny() {
+		t.Errorf("Load outside a repo = %+v; want empty", ctx)
+	}
+}
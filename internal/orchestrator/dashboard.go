@@ -0,0 +1,147 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/tui"
+)
+
+// tracker mirrors per-task progress to the live dashboard. A nil tracker is a
+// no-op, so the run loop can report unconditionally.
+type tracker struct {
+	mu     sync.Mutex
+	dash   *tui.Dashboard
+	states map[string]*tui.TaskState
+}
+
+func newTracker(tasks []parser.Task, maxIter int, statuses []string) (*tracker, []tui.TaskState) {
+	t := &tracker{states: make(map[string]*tui.TaskState, len(tasks))}
+	initial := make([]tui.TaskState, len(tasks))
+	for i, task := range tasks {
+		initial[i] = tui.TaskState{
+			Slug:          task.Slug,
+			Model:         task.Model,
+			Status:        statuses[i],
+			MaxIterations: maxIter,
+		}
+		state := initial[i]
+		t.states[task.Slug] = &state
+	}
+	return t, initial
+}
+
+// update applies fn to the task's state and pushes the result to the dashboard.
+func (t *tracker) update(slug string, fn func(*tui.TaskState)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	state, ok := t.states[slug]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	fn(state)
+	snapshot := *state
+	dash := t.dash
+	t.mu.Unlock()
+
+	if dash != nil {
+		dash.Update(snapshot)
+	}
+}
+
+// status records a status change, stamping start and finish times.
+func (t *tracker) status(slug, status string) {
+	t.update(slug, func(s *tui.TaskState) {
+		switch status {
+		case "pending":
+			s.Started, s.Finished = time.Time{}, time.Time{}
+			s.Iteration = 0
+		case "running":
+			s.Started, s.Finished = time.Now(), time.Time{}
+		default:
+			if !s.Started.IsZero() {
+				s.Finished = time.Now()
+			}
+		}
+		s.Status = status
+	})
+}
+
+// iteration records the iteration a task is on and the log it writes to.
+func (t *tracker) iteration(slug string, iter int, logPath string) {
+	t.update(slug, func(s *tui.TaskState) {
+		s.Iteration = iter
+		s.LogPath = logPath
+	})
+}
+
+// taskControl owns the per-task contexts of a run and implements
+// tui.Controller so the dashboard can cancel and re-run tasks.
+type taskControl struct {
+	parent    context.Context
+	cancelRun context.CancelFunc // cancels parent, and with it the run
+	mu        sync.Mutex
+	ctxs      map[string]context.Context
+	cancels   map[string]context.CancelFunc
+
+	// rerun starts the task again. execute sets it before the dashboard
+	// starts, so the UI goroutine never sees it change.
+	rerun func(slug string)
+}
+
+func newTaskControl(parent context.Context, cancelRun context.CancelFunc, tasks []parser.Task) *taskControl {
+	c := &taskControl{
+		parent:    parent,
+		cancelRun: cancelRun,
+		ctxs:      make(map[string]context.Context, len(tasks)),
+		cancels:   make(map[string]context.CancelFunc, len(tasks)),
+	}
+	for _, t := range tasks {
+		c.reset(t.Slug)
+	}
+	return c
+}
+
+// reset gives the task a fresh context and returns it.
+func (c *taskControl) reset(slug string) context.Context {
+	ctx, cancel := context.WithCancel(c.parent)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old := c.cancels[slug]; old != nil {
+		old()
+	}
+	c.ctxs[slug], c.cancels[slug] = ctx, cancel
+	return ctx
+}
+
+// taskCtx returns the task's current context.
+func (c *taskControl) taskCtx(slug string) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctxs[slug]
+}
+
+func (c *taskControl) Cancel(slug string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel := c.cancels[slug]; cancel != nil {
+		cancel()
+	}
+}
+
+// CancelAll cancels the whole run, as an interrupt would: output and PRs are
+// skipped and the worktrees kept for 'mochi resume'.
+func (c *taskControl) CancelAll() {
+	c.cancelRun()
+}
+
+func (c *taskControl) Rerun(slug string) {
+	if c.rerun != nil {
+		c.rerun(slug)
+	}
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+// events returns the run's event bus, delivering to OnEvent, the event log
+// and the configured hooks, and a function that flushes them at the end of
+// the run.
+func (o Options) events(cfg config.Config, repoRoot string, p *printer) (*event.Bus, func(), error) {
+	bus := &event.Bus{}
+	if o.OnEvent != nil {
+		bus.Subscribe(o.OnEvent)
+	}
+	var closers []func()
+	if len(cfg.Hooks) > 0 {
+		hooks, err := event.NewHooks(repoRoot, cfg.Hooks, func(err error) { p.warn(err.Error()) })
+		if err != nil {
+			return nil, nil, err
+		}
+		bus.Subscribe(hooks.Handle)
+		closers = append(closers, hooks.Close)
+	}
+	if cfg.EventLog != "" {
+		log, err := event.OpenLog(cfg.EventLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		bus.Subscribe(log.Handle)
+		closers = append(closers, func() {
+			if err := log.Close(); err != nil {
+				p.warn(err.Error())
+			}
+		})
+	}
+	return bus, func() {
+		for _, c := range closers {
+			c()
+		}
+	}, nil
+}
+
+func verificationEvent(slug string, iter int, results []verify.Result) event.Event {
+	e := event.Event{Type: event.VerificationDone, Task: slug, Iteration: iter, Status: "passed"}
+	if failed := verify.Failed(results); len(failed) > 0 {
+		e.Status = "failed"
+		e.Message = strings.Join(failed, "\n")
+	}
+	return e
+}
+
+// outputEvent reports where a task's output went: a file, or the URL of the
+// issue filed for it.
+func outputEvent(slug, dest string) event.Event {
+	e := event.Event{Type: event.OutputWritten, Task: slug}
+	url, _, _ := strings.Cut(dest, " ")
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		e.URL = url
+		e.Message = dest
+	} else {
+		e.Path = dest
+	}
+	return e
+}
@@ -0,0 +1,64 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
+)
+
+// Options control how a run reports its progress. The zero value runs in the
+// current directory, prints progress to stdout and reports no events.
+type Options struct {
+	// RepoRoot is the repository to run in. Relative paths in the config
+	// (task file, worktree, log and output directories, reports) are taken
+	// relative to it. Empty means the current directory.
+	RepoRoot string
+
+	// Out receives progress lines; nil means os.Stdout. A run serializes its
+	// own writes; runs that share a writer need one that is safe for
+	// concurrent use, as os.Stdout is.
+	Out io.Writer
+
+	// OnEvent, if set, is called for every lifecycle event, one at a time and
+	// in order. It runs on the goroutine that published the event, so it
+	// should return quickly.
+	OnEvent func(event.Event)
+}
+
+// printer returns a progress printer for one run.
+func (o Options) printer() *printer {
+	if o.Out == nil {
+		return &printer{w: os.Stdout}
+	}
+	return &printer{w: o.Out}
+}
+
+// setup applies the options to a run: it resolves the repo root and anchors
+// cfg's relative paths there.
+func (o Options) setup(cfg *config.Config) (string, error) {
+	if o.RepoRoot == "" {
+		return os.Getwd()
+	}
+	repoRoot, err := filepath.Abs(o.RepoRoot)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve repo root %q: %w", o.RepoRoot, err)
+	}
+	for _, p := range []*string{&cfg.WorktreeDir, &cfg.LogDir, &cfg.OutputDir, &cfg.ReportJSON, &cfg.ReportJUnit, &cfg.EventLog} {
+		if *p != "" {
+			*p = inRepo(repoRoot, *p)
+		}
+	}
+	return repoRoot, nil
+}
+
+// inRepo returns path relative to repoRoot unless it is absolute.
+func inRepo(repoRoot, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(repoRoot, path)
+}
@@ -2,21 +2,30 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/thisguymartin/ai-forge/internal/agent"
 	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
 	gh "github.com/thisguymartin/ai-forge/internal/github"
+	"github.com/thisguymartin/ai-forge/internal/learnings"
 	"github.com/thisguymartin/ai-forge/internal/memory"
 	"github.com/thisguymartin/ai-forge/internal/output"
 	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/report"
 	"github.com/thisguymartin/ai-forge/internal/reviewer"
+	"github.com/thisguymartin/ai-forge/internal/tui"
+	"github.com/thisguymartin/ai-forge/internal/verify"
 	"github.com/thisguymartin/ai-forge/internal/workspace"
 	"github.com/thisguymartin/ai-forge/internal/worktree"
 )
@@ -26,29 +35,47 @@ type LoopResult struct {
 	FinalWorkerResult agent.Result
 	Iterations        int
 	FinalMemory       memory.Context
+	Duration          time.Duration // wall time of the whole loop
 }
 
 // checkDependencies verifies that all required external tools are present in PATH.
-// It always checks for git; checks claude or gemini based on the default model prefix;
-// and checks gh when --create-prs or --issue is used.
+// It always checks for git; checks the CLI of the provider selected for the default
+// model and each reviewer model; and checks gh when --create-prs, --issue or the
+// issue output mode is used.
 // Returns a combined error listing all missing tools with install hints.
-func checkDependencies(cfg config.Config) error {
+func checkDependencies(cfg config.Config, providers *agent.Registry) error {
 	type tool struct {
 		name    string
 		install string
 	}
 
+	installHints := map[string]string{
+		"claude": "https://claude.ai/code",
+		"gemini": "https://ai.google.dev/gemini-api/docs/gemini-cli",
+	}
+
 	var needed []tool
 
 	needed = append(needed, tool{"git", "https://git-scm.com"})
 
-	if strings.HasPrefix(cfg.Model, "gemini-") {
-		needed = append(needed, tool{"gemini", "https://ai.google.dev/gemini-api/docs/gemini-cli"})
-	} else {
-		needed = append(needed, tool{"claude", "https://claude.ai/code"})
+	seen := make(map[string]bool)
+	for _, model := range append([]string{cfg.Model, cfg.SummaryModel}, cfg.Reviewers()...) {
+		if model == "" {
+			continue
+		}
+		bin := providers.For(model).Binary()
+		if bin == "" || seen[bin] {
+			continue
+		}
+		seen[bin] = true
+		hint := installHints[bin]
+		if hint == "" {
+			hint = "your provider's documentation"
+		}
+		needed = append(needed, tool{bin, hint})
 	}
 
-	if cfg.CreatePRs || cfg.IssueNumber > 0 {
+	if cfg.CreatePRs || cfg.IssueNumber > 0 || cfg.OutputMode == string(output.ModeIssue) {
 		needed = append(needed, tool{"gh", "https://cli.github.com"})
 	}
 
@@ -74,18 +101,62 @@ func checkDependencies(cfg config.Config) error {
 	return fmt.Errorf("%s", msg)
 }
 
+// newProviders builds the run's provider registry from the command-template
+// providers declared in config, which take precedence over the built-in claude
+// and gemini providers. They are not registered globally, so other runs in the
+// process do not see them.
+func newProviders(cfg config.Config) (*agent.Registry, error) {
+	ps := make([]agent.Provider, 0, len(cfg.Providers))
+	for _, pc := range cfg.Providers {
+		p, err := agent.NewTemplateProvider(agent.TemplateSpec{
+			Name:           pc.Name,
+			Match:          pc.Match,
+			Command:        pc.Command,
+			SuccessPattern: pc.SuccessPattern,
+			OutputPattern:  pc.OutputPattern,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		ps = append(ps, p)
+	}
+	return agent.NewRegistry(ps...), nil
+}
+
+// preflight resolves the configured providers and checks the review policy
+// and the CLIs the run needs.
+func preflight(cfg config.Config) (*agent.Registry, error) {
+	providers, err := newProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := reviewer.ParsePolicy(cfg.ReviewPolicy); err != nil {
+		return nil, err
+	}
+	return providers, checkDependencies(cfg, providers)
+}
+
 // Run is the main entry point for a MOCHI execution cycle.
-// It orchestrates parsing, worktree creation, agent invocation, PR creation, and cleanup.
-func Run(cfg config.Config) error {
+// It orchestrates parsing, worktree creation, agent invocation, PR creation, and cleanup,
+// and returns the record of the run. Failed tasks are reported in the record, not
+// as an error. Cancelling ctx stops every running agent and marks unfinished tasks cancelled.
+func Run(ctx context.Context, cfg config.Config, opts Options) (report.Run, error) {
+	p := opts.printer()
+	repoRoot, err := opts.setup(&cfg)
+	if err != nil {
+		return report.Run{}, err
+	}
+
 	// ── 0. Dependency checks ────────────────────────────────────────────────
-	if err := checkDependencies(cfg); err != nil {
-		return err
+	providers, err := preflight(cfg)
+	if err != nil {
+		return report.Run{}, err
 	}
 
 	// ── 1. Resolve task source ─────────────────────────────────────────────
-	taskFile, cleanup, err := resolveTaskFile(cfg)
+	taskFile, cleanup, err := resolveTaskFile(cfg, repoRoot)
 	if err != nil {
-		return err
+		return report.Run{}, err
 	}
 	if cleanup != nil {
 		defer cleanup()
@@ -94,14 +165,17 @@ func Run(cfg config.Config) error {
 	// ── 2. Parse tasks ─────────────────────────────────────────────────────
 	tasks, err := parser.ParseFile(taskFile)
 	if err != nil {
-		return err
+		return report.Run{}, err
 	}
 
 	// Apply single-task filter
 	if cfg.TaskFilter != "" {
 		tasks = filterBySlug(tasks, cfg.TaskFilter)
 		if len(tasks) == 0 {
-			return fmt.Errorf("no task found with slug %q", cfg.TaskFilter)
+			return report.Run{}, fmt.Errorf("no task found with slug %q", cfg.TaskFilter)
+		}
+		for _, dropped := range dropMissingDependencies(tasks) {
+			p.warn(fmt.Sprintf("Ignoring dependency %s (not part of this run)", dropped))
 		}
 	}
 
@@ -122,9 +196,13 @@ func Run(cfg config.Config) error {
 	}
 
 	if needsAiSlug {
-		printSection("Refining branch titles...")
+		p.section("Refining branch titles...")
+		oldSlugs := make([]string, len(tasks))
+		for i, t := range tasks {
+			oldSlugs[i] = t.Slug
+		}
+
 		var slugWg sync.WaitGroup
-		var slugCtx = context.Background()
 
 		for i := range tasks {
 			// If the branch slug was manually provided or is reasonably short, keep it.
@@ -140,113 +218,460 @@ func Run(cfg config.Config) error {
 						promptContext += "\n\n" + tasks[idx].Description
 					}
 
-					newSlug, err := agent.GenerateTitle(slugCtx, tasks[idx].Model, promptContext)
+					newSlug, err := agent.GenerateTitle(ctx, providers, tasks[idx].Model, promptContext)
 					if err == nil && newSlug != "" {
 						tasks[idx].Slug = newSlug
 					} else if cfg.Verbose {
-						printWarn(fmt.Sprintf("Failed to generate AI title for task %d: %v", idx+1, err))
+						p.warn(fmt.Sprintf("Failed to generate AI title for task %d: %v", idx+1, err))
 					}
 				}(i)
 			}
 		}
 		slugWg.Wait()
+
+		// Keep [after:<slug>] references pointing at the refined slugs.
+		renamed := make(map[string]string, len(tasks))
+		for i, t := range tasks {
+			renamed[oldSlugs[i]] = t.Slug
+		}
+		for i := range tasks {
+			for j, dep := range tasks[i].DependsOn {
+				if slug, ok := renamed[dep]; ok {
+					tasks[i].DependsOn[j] = slug
+				}
+			}
+		}
 	}
 
-	printSection(fmt.Sprintf("Found %d task(s): %s", len(tasks), slugList(tasks)))
+	p.section(fmt.Sprintf("Found %d task(s): %s", len(tasks), slugList(tasks)))
 
 	// ── 4. Dry run ─────────────────────────────────────────────────────────
 	if cfg.DryRun {
-		return printDryRun(tasks, cfg)
+		return report.Run{}, p.dryRun(tasks, cfg)
 	}
 
 	// ── 5. Setup ───────────────────────────────────────────────────────────
-	repoRoot, err := os.Getwd()
-	if err != nil {
-		return err
+	if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
+		return report.Run{}, fmt.Errorf("cannot create log dir %q: %w", cfg.LogDir, err)
 	}
 
-	if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
-		return fmt.Errorf("cannot create log dir %q: %w", cfg.LogDir, err)
+	bus, closeEvents, err := opts.events(cfg, repoRoot, p)
+	if err != nil {
+		return report.Run{}, err
 	}
+	defer closeEvents()
+	bus.Publish(event.Event{Type: event.RunStarted, Message: fmt.Sprintf("%d task(s)", len(tasks))})
 
+	if cfg.RunID == "" {
+		cfg.RunID = worktree.NewRunID()
+	}
 	wm := worktree.NewManager(repoRoot, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+	wm.RunID = cfg.RunID
 
 	// ── 5. Create worktrees ────────────────────────────────────────────────
-	printSection("Creating worktrees...")
+	p.section(fmt.Sprintf("Creating worktrees (run %s)...", cfg.RunID))
 	entries := make([]*worktree.Entry, 0, len(tasks))
 	for _, t := range tasks {
 		entry, err := wm.Create(t.Slug)
 		if err != nil {
-			printFail(fmt.Sprintf("%-30s %v", t.Slug, err))
-			return err
+			p.fail(fmt.Sprintf("%-30s %v", t.Slug, err))
+			return report.Run{}, err
+		}
+		task := t
+		entry.Task = &task
+		if err := wm.Update(t.Slug, func(e *worktree.Entry) { e.Task = &task }); err != nil {
+			return report.Run{}, err
 		}
 		entries = append(entries, entry)
-		printSuccess(fmt.Sprintf("%-30s (%s)", entry.Path, entry.Branch))
+		p.success(fmt.Sprintf("%-30s (%s)", entry.Path, entry.Branch))
+		bus.Publish(event.Event{Type: event.WorktreeCreated, Task: t.Slug, Path: entry.Path, Message: entry.Branch})
 	}
 
 	// ── 5b. Launch workspace (if --workspace is set) ───────────────────────
 	if cfg.Workspace != "" {
-		printSection("Launching workspace...")
+		p.section("Launching workspace...")
 		if err := workspace.Launch(workspace.Options{
 			Mode:    cfg.Workspace,
 			Entries: entries,
 			Verbose: cfg.Verbose,
 		}); err != nil {
-			printWarn(fmt.Sprintf("Workspace launch failed: %v", err))
+			p.warn(fmt.Sprintf("Workspace launch failed: %v", err))
+		}
+	}
+
+	return execute(ctx, cfg, p, providers, bus, wm, repoRoot, tasks, entries)
+}
+
+// Resume continues an interrupted run from the worktree manifest. Tasks already
+// marked done are not re-run; every other task re-enters the Ralph Loop in its
+// existing worktree, picking up the memory files left by the previous run.
+// Output dispatch and PR creation then proceed as in Run.
+func Resume(ctx context.Context, cfg config.Config, opts Options) (report.Run, error) {
+	p := opts.printer()
+	repoRoot, err := opts.setup(&cfg)
+	if err != nil {
+		return report.Run{}, err
+	}
+	providers, err := preflight(cfg)
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
+		return report.Run{}, fmt.Errorf("cannot create log dir %q: %w", cfg.LogDir, err)
+	}
+
+	wm := worktree.NewManager(repoRoot, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+
+	manifest, err := wm.Entries()
+	if err != nil {
+		return report.Run{}, fmt.Errorf("cannot read worktree manifest: %w", err)
+	}
+
+	if cfg.RunID == "" {
+		cfg.RunID = worktree.LatestRun(manifest)
+	}
+	manifest = worktree.RunEntries(manifest, cfg.RunID)
+	wm.RunID = cfg.RunID
+
+	bySlug := make(map[string]*worktree.Entry, len(manifest))
+	var tasks []parser.Task
+	for _, e := range manifest {
+		if e.Task == nil {
+			p.warn(fmt.Sprintf("Skipping %-24s (no task recorded in manifest)", e.Slug))
+			continue
+		}
+		if _, statErr := os.Stat(e.Path); statErr != nil {
+			p.warn(fmt.Sprintf("Skipping %-24s (worktree missing — run 'mochi prune')", e.Slug))
+			continue
+		}
+		bySlug[e.Slug] = e
+		tasks = append(tasks, *e.Task)
+	}
+	if len(tasks) == 0 {
+		if cfg.RunID != "" {
+			return report.Run{}, fmt.Errorf("nothing to resume: no resumable entries for run %s in the worktree manifest", cfg.RunID)
+		}
+		return report.Run{}, fmt.Errorf("nothing to resume: no resumable entries in the worktree manifest")
+	}
+
+	for _, dropped := range dropMissingDependencies(tasks) {
+		p.warn(fmt.Sprintf("Ignoring dependency %s (not in manifest)", dropped))
+	}
+	tasks, err = parser.OrderByDependencies(tasks)
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	entries := make([]*worktree.Entry, len(tasks))
+	pending := 0
+	for i, t := range tasks {
+		entries[i] = bySlug[t.Slug]
+		if entries[i].Status != "done" {
+			pending++
+		}
+	}
+
+	resuming := "Resuming"
+	if cfg.RunID != "" {
+		resuming += " run " + cfg.RunID + ":"
+	}
+	p.section(fmt.Sprintf("%s %d task(s), %d already done: %s", resuming, len(tasks), len(tasks)-pending, slugList(tasks)))
+
+	bus, closeEvents, err := opts.events(cfg, repoRoot, p)
+	if err != nil {
+		return report.Run{}, err
+	}
+	defer closeEvents()
+	bus.Publish(event.Event{Type: event.RunStarted, Message: fmt.Sprintf("resuming %d task(s), %d already done", len(tasks), len(tasks)-pending)})
+
+	return execute(ctx, cfg, p, providers, bus, wm, repoRoot, tasks, entries)
+}
+
+// execute runs the Ralph Loop for every task whose entry is not already done,
+// then dispatches output, opens PRs, cleans up, prints the summary and returns
+// the run record.
+// tasks must be in dependency order and entries[i] must belong to tasks[i].
+//
+// When ctx is cancelled, running agents are stopped, unfinished tasks are
+// marked cancelled, output and PRs are skipped, and worktrees are kept for
+// 'mochi resume' unless KeepOnCancel is off. Otherwise worktrees are removed,
+// except those of tasks cancelled from the dashboard when KeepOnCancel is on
+// and of failed and skipped tasks when KeepFailed is on ('mochi retry').
+func execute(ctx context.Context, cfg config.Config, p *printer, providers *agent.Registry, bus *event.Bus, wm *worktree.Manager, repoRoot string, tasks []parser.Task, entries []*worktree.Entry) (report.Run, error) {
+	started := time.Now()
+	for i, t := range tasks {
+		if entries[i].Status != "done" {
+			bus.Publish(event.Event{Type: event.TaskQueued, Task: t.Slug, Model: t.Model})
 		}
 	}
 
 	// ── 6. Invoke agents (via Ralph Loop) ──────────────────────────────────
-	printSection("Invoking agents...")
+	p.section("Invoking agents...")
 	results := make([]agent.Result, len(tasks))
 	loopResults := make([]LoopResult, len(tasks))
 
+	// Tasks arrive in dependency order. A task starts only after its
+	// prerequisites finish, and its worktree is stacked on their branches.
+	index := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		index[t.Slug] = i
+	}
+
+	// Each task runs under its own context so the dashboard can cancel it.
+	// Cancelling all of them from the dashboard cancels ctx, the run itself.
+	ctx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+	ctrl := newTaskControl(ctx, cancelRun, tasks)
+
+	// Learnings from earlier runs are fed to the workers; new ones are saved
+	// as each task finishes.
+	var lessons *learnings.Store
+	if cfg.Learnings {
+		s, err := learnings.Open(filepath.Join(repoRoot, learnings.File))
+		if err != nil {
+			p.warn(fmt.Sprintf("learnings disabled: %v", err))
+		} else {
+			lessons = s
+		}
+	}
+
+	// The dashboard, if any, starts once the re-run hook below is set.
+	var tr *tracker
+	var dash *tui.Dashboard
+	var progress io.Writer // restored once the dashboard closes
+
+	prep := newPreparer(cfg, repoRoot)
+
+	// statuses mirrors the manifest status of each task for the run report.
+	statuses := make([]string, len(tasks))
+
+	// mu guards results, loopResults, statuses and entries: dashboard re-runs
+	// update them while other tasks read their prerequisites' results.
+	var mu sync.Mutex
+
+	setStatus := func(idx int, status string) {
+		mu.Lock()
+		statuses[idx] = status
+		err := results[idx].Error
+		mu.Unlock()
+		_ = wm.UpdateStatus(tasks[idx].Slug, status)
+		tr.status(tasks[idx].Slug, status)
+		e := event.Event{Type: event.TaskFinished, Task: tasks[idx].Slug, Status: status, Model: tasks[idx].Model}
+		if status == "running" {
+			e.Type = event.TaskStarted
+		} else if err != nil {
+			e.Message = err.Error()
+		}
+		bus.Publish(e)
+	}
+
+	// finish records a task's final result and status.
+	finish := func(idx int, lr LoopResult, status string) {
+		mu.Lock()
+		results[idx], loopResults[idx] = lr.FinalWorkerResult, lr
+		mu.Unlock()
+		setStatus(idx, status)
+		p.loopResult(lr)
+	}
+
+	var runTask func(idx int, force bool)
+	runTask = func(idx int, force bool) {
+		task := tasks[idx]
+		taskCtx := ctrl.taskCtx(task.Slug)
+		mu.Lock()
+		entry := entries[idx]
+		mu.Unlock()
+
+		// Finished in an earlier run (resume): reuse its worktree as-is.
+		if entry.Status == "done" && !force {
+			r := agent.Result{Slug: task.Slug, Success: true}
+			mu.Lock()
+			results[idx] = r
+			statuses[idx] = "done"
+			loopResults[idx] = LoopResult{
+				FinalWorkerResult: r,
+				Iterations:        entry.Iteration,
+				FinalMemory:       memory.Load(entry.Path),
+			}
+			mu.Unlock()
+			p.success(fmt.Sprintf("%-30s already done", task.Slug))
+			return
+		}
+
+		if taskCtx.Err() != nil {
+			r := agent.Result{Slug: task.Slug, Error: fmt.Errorf("cancelled before start: %w", context.Canceled)}
+			finish(idx, LoopResult{FinalWorkerResult: r}, "cancelled")
+			return
+		}
+
+		mu.Lock()
+		dep := failedPrerequisite(task, index, results)
+		branches := make([]string, len(task.DependsOn))
+		for j, d := range task.DependsOn {
+			branches[j] = entries[index[d]].Branch
+		}
+		mu.Unlock()
+
+		if dep != "" {
+			r := agent.Result{Slug: task.Slug, Error: fmt.Errorf("skipped: prerequisite %q did not succeed", dep)}
+			finish(idx, LoopResult{FinalWorkerResult: r}, "skipped")
+			return
+		}
+
+		if len(branches) > 0 {
+			merged, err := wm.MergeBranches(task.Slug, branches)
+			if err != nil {
+				finish(idx, LoopResult{FinalWorkerResult: agent.Result{Slug: task.Slug, Error: err}}, "failed")
+				return
+			}
+			entry = merged
+			mu.Lock()
+			entries[idx] = entry
+			mu.Unlock()
+		}
+
+		p.info(fmt.Sprintf("⟳  %-28s [%s]", task.Slug, task.Model))
+		setStatus(idx, "running")
+
+		// Seed and set up a fresh worktree; a failure fails the task before
+		// its agent is invoked.
+		if prep.Enabled() && !entry.Prepared {
+			if r := prepareWorktree(taskCtx, cfg, prep, wm, entry); !r.Success {
+				status := "failed"
+				if taskCtx.Err() != nil {
+					status = "cancelled"
+				}
+				finish(idx, LoopResult{FinalWorkerResult: r, Duration: r.Duration}, status)
+				return
+			}
+		}
+
+		started := time.Now()
+		lr := runRalphLoop(taskCtx, cfg, p, providers, wm, task, entry, tr, lessons, bus)
+		lr.Duration = time.Since(started)
+		if err := lessons.Save(); err != nil {
+			p.warn(fmt.Sprintf("cannot save learnings: %v", err))
+		}
+		status := statusStr(lr.FinalWorkerResult.Success)
+		if !lr.FinalWorkerResult.Success && taskCtx.Err() != nil {
+			status = "cancelled"
+		}
+		finish(idx, lr, status)
+	}
+
+	// Re-runs requested from the dashboard start from iteration 1 in the
+	// task's existing worktree, keeping its memory files.
+	var rerunWg sync.WaitGroup
+	ctrl.rerun = func(slug string) {
+		idx, ok := index[slug]
+		if !ok {
+			return
+		}
+		ctrl.reset(slug)
+		rerunWg.Add(1)
+		go func() {
+			defer rerunWg.Done()
+			tr.status(slug, "pending")
+			mu.Lock()
+			entries[idx].Iteration = 0
+			mu.Unlock()
+			runTask(idx, true)
+		}()
+	}
+
+	if cfg.Dashboard {
+		if tui.DashboardSupported() {
+			shown := make([]string, len(tasks))
+			for i, e := range entries {
+				shown[i] = "pending"
+				if e.Status == "done" {
+					shown[i] = "done"
+				}
+			}
+			var initial []tui.TaskState
+			tr, initial = newTracker(tasks, max(cfg.MaxIterations, 1), shown)
+			// Live agent output and progress lines would corrupt the screen;
+			// results are printed once the dashboard closes.
+			cfg.Verbose = false
+			progress = p.setWriter(io.Discard)
+			dash = tui.StartDashboard(initial, ctrl)
+			tr.dash = dash
+		} else {
+			p.warn("--dashboard needs an interactive terminal; using plain output")
+		}
+	}
+
 	if cfg.Sequential {
-		for i, t := range tasks {
-			printInfo(fmt.Sprintf("⟳  %-28s [%s]", t.Slug, t.Model))
-			_ = wm.UpdateStatus(t.Slug, "running")
-			loopResults[i] = runRalphLoop(cfg, t, entries[i])
-			results[i] = loopResults[i].FinalWorkerResult
-			_ = wm.UpdateStatus(t.Slug, statusStr(results[i].Success))
-			printLoopResult(loopResults[i])
+		for i := range tasks {
+			runTask(i, false)
 		}
 	} else {
 		// Semaphore channel limits concurrent worktrees when --worktrees N is set.
 		var sem chan struct{}
 		if cfg.MaxWorktrees > 0 && cfg.MaxWorktrees < len(tasks) {
 			sem = make(chan struct{}, cfg.MaxWorktrees)
-			printInfo(fmt.Sprintf("Concurrency limited to %d worktree(s)", cfg.MaxWorktrees))
+			p.info(fmt.Sprintf("Concurrency limited to %d worktree(s)", cfg.MaxWorktrees))
+		}
+
+		finished := make([]chan struct{}, len(tasks))
+		for i := range finished {
+			finished[i] = make(chan struct{})
 		}
 
 		var wg sync.WaitGroup
-		for i, t := range tasks {
+		for i := range tasks {
 			wg.Add(1)
-			go func(idx int, task parser.Task, entry *worktree.Entry) {
+			go func(idx int) {
 				defer wg.Done()
+				defer close(finished[idx])
+				// Wait for prerequisites before taking a slot so blocked
+				// dependents never starve the tasks they are waiting on.
+				for _, dep := range tasks[idx].DependsOn {
+					<-finished[index[dep]]
+				}
 				if sem != nil {
 					sem <- struct{}{}        // acquire
 					defer func() { <-sem }() // release
 				}
-				printInfo(fmt.Sprintf("⟳  %-28s [%s]", task.Slug, task.Model))
-				_ = wm.UpdateStatus(task.Slug, "running")
-				loopResults[idx] = runRalphLoop(cfg, task, entry)
-				results[idx] = loopResults[idx].FinalWorkerResult
-				_ = wm.UpdateStatus(task.Slug, statusStr(results[idx].Success))
-				printLoopResult(loopResults[idx])
-			}(i, t, entries[i])
+				runTask(idx, false)
+			}(i)
 		}
 		wg.Wait()
 	}
 
+	// The dashboard stays up until the user leaves it, which is only
+	// possible once neither the initial run nor any re-run is active.
+	// A cancelled run closes it straight away.
+	if dash != nil {
+		if ctx.Err() != nil {
+			dash.Close()
+		}
+		dash.Wait()
+		rerunWg.Wait()
+		p.setWriter(progress)
+		for _, lr := range loopResults {
+			p.loopResult(lr)
+		}
+	}
+
+	cancelled := ctx.Err() != nil
+	if cancelled {
+		p.section("Run cancelled — skipping output and pull requests")
+	}
+
 	// ── 7. Post-loop output dispatch ───────────────────────────────────────
-	if cfg.OutputMode != "" && cfg.OutputMode != string(output.ModePR) {
-		printSection(fmt.Sprintf("Writing output (%s)...", cfg.OutputMode))
+	var outputs []string // where each task's output went, for the run report
+	if !cancelled && cfg.OutputMode != "" && cfg.OutputMode != string(output.ModePR) {
+		p.section(fmt.Sprintf("Writing output (%s)...", cfg.OutputMode))
+		var handled []output.Options
+		outputs = make([]string, len(tasks))
 		for i, t := range tasks {
 			if !results[i].Success {
-				printWarn(fmt.Sprintf("Skipping output for %-24s (agent failed)", t.Slug))
+				p.warn(fmt.Sprintf("Skipping output for %-24s (%s)", t.Slug, failureReason(results[i])))
 				continue
 			}
-			if err := output.Handle(output.Options{
+			opts := output.Options{
 				Mode:         output.Mode(cfg.OutputMode),
 				Task:         t,
 				Entry:        entries[i],
@@ -255,106 +680,226 @@ func Run(cfg config.Config) error {
 				Iterations:   loopResults[i].Iterations,
 				OutputDir:    cfg.OutputDir,
 				RepoRoot:     repoRoot,
-			}); err != nil {
-				printFail(fmt.Sprintf("Output failed for %s: %v", t.Slug, err))
+
+				IssueLabels:    cfg.IssueLabels,
+				IssueAssignees: cfg.IssueAssignees,
+			}
+			if dest, err := output.Handle(opts); err != nil {
+				p.fail(fmt.Sprintf("Output failed for %s: %v", t.Slug, err))
 			} else {
-				printSuccess(fmt.Sprintf("%-30s written to %s/", t.Slug, cfg.OutputDir))
+				p.success(fmt.Sprintf("%-30s %s", t.Slug, dest))
+				outputs[i] = dest
+				bus.Publish(outputEvent(t.Slug, dest))
+				handled = append(handled, opts)
+			}
+		}
+		if len(handled) > 0 {
+			paths, err := output.Finalize(output.Mode(cfg.OutputMode), cfg.OutputDir, handled)
+			if err != nil {
+				p.fail(fmt.Sprintf("Run report failed: %v", err))
+			}
+			for _, path := range paths {
+				p.success(fmt.Sprintf("%-30s %s", "run report", path))
 			}
 		}
 	}
 
 	// ── 8. Create PRs ──────────────────────────────────────────────────────
-	if cfg.CreatePRs && cfg.OutputMode == string(output.ModePR) {
-		printSection("Creating pull requests...")
+	if !cancelled && cfg.CreatePRs && cfg.OutputMode == string(output.ModePR) {
+		p.section("Creating pull requests...")
 		for i, t := range tasks {
 			if !results[i].Success {
-				printWarn(fmt.Sprintf("Skipping PR for %-24s (agent failed)", t.Slug))
+				p.warn(fmt.Sprintf("Skipping PR for %-24s (%s)", t.Slug, failureReason(results[i])))
 				continue
 			}
-			if err := gh.PushBranch(repoRoot, entries[i].Branch); err != nil {
-				printFail(fmt.Sprintf("Push failed for %s: %v", t.Slug, err))
+			if entries[i].PRURL != "" {
+				// A retried task adds commits to its open PR.
+				if err := gh.PushBranch(repoRoot, entries[i].Branch); err != nil {
+					p.fail(fmt.Sprintf("Push failed for %s: %v", t.Slug, err))
+				} else {
+					p.success(fmt.Sprintf("%-30s %s (already open)", t.Slug, entries[i].PRURL))
+				}
 				continue
 			}
-			// Use the last iteration log if available, else fallback to base slug
-			logPath := filepath.Join(cfg.LogDir, t.Slug+".log")
-			if loopResults[i].Iterations > 1 {
-				logPath = filepath.Join(cfg.LogDir, fmt.Sprintf("%s-iter%d.log", t.Slug, loopResults[i].Iterations))
+			// Use the log of the last iteration that ran
+			logPath := agent.LogPath(cfg.LogDir, t.Slug, loopResults[i].Iterations, cfg.MaxIterations)
+			// Stack the PR on its prerequisite when there is exactly one.
+			base := ""
+			if len(t.DependsOn) == 1 {
+				base = entries[index[t.DependsOn[0]]].Branch
 			}
-			url, err := gh.CreatePR(gh.PROptions{
+			pr := gh.PROptions{
 				Slug:     t.Slug,
 				Branch:   entries[i].Branch,
+				Base:     base,
 				Task:     t.Title,
 				LogPath:  logPath,
 				RepoRoot: repoRoot,
-			})
+				Review:   reviewer.StoredMarkdown(loopResults[i].FinalMemory.Review),
+			}
+			body, err := output.Render(output.PRTemplate, output.Options{
+				Mode:         output.ModePR,
+				Task:         t,
+				Entry:        entries[i],
+				WorkerResult: results[i],
+				MemCtx:       loopResults[i].FinalMemory,
+				Iterations:   loopResults[i].Iterations,
+				RepoRoot:     repoRoot,
+			}, gh.BuildPRBody(pr))
+			if err != nil {
+				p.fail(fmt.Sprintf("PR failed for %s: %v", t.Slug, err))
+				continue
+			}
+			pr.Body = body
+			if err := gh.PushBranch(repoRoot, entries[i].Branch); err != nil {
+				p.fail(fmt.Sprintf("Push failed for %s: %v", t.Slug, err))
+				continue
+			}
+			url, err := gh.CreatePR(pr)
 			if err != nil {
-				printFail(fmt.Sprintf("PR failed for %s: %v", t.Slug, err))
+				p.fail(fmt.Sprintf("PR failed for %s: %v", t.Slug, err))
 			} else {
-				printSuccess(fmt.Sprintf("%-30s %s", t.Slug, url))
+				_ = wm.Update(t.Slug, func(e *worktree.Entry) { e.PRURL = url })
+				entries[i].PRURL = url
+				bus.Publish(event.Event{Type: event.PRCreated, Task: t.Slug, URL: url, Message: entries[i].Branch})
+				p.success(fmt.Sprintf("%-30s %s", t.Slug, url))
 			}
 		}
 	}
 
 	// ── 9. Cleanup worktrees ───────────────────────────────────────────────
-	if !cfg.KeepWorktrees {
-		printSection("Cleaning up worktrees...")
+	if cancelled && cfg.KeepOnCancel && !cfg.KeepWorktrees {
+		p.info("Worktrees kept — run 'mochi resume' to continue")
+	} else if !cfg.KeepWorktrees {
+		p.section("Cleaning up worktrees...")
+		kept := keptWorktrees(cfg, wm, tasks, statuses)
 		for _, t := range tasks {
+			if reason := kept[t.Slug]; reason != "" {
+				p.info(fmt.Sprintf("Kept %-25s %s", t.Slug, reason))
+				continue
+			}
 			if err := wm.Destroy(t.Slug); err != nil {
-				printWarn(fmt.Sprintf("cleanup failed for %s: %v", t.Slug, err))
+				p.warn(fmt.Sprintf("cleanup failed for %s: %v", t.Slug, err))
+			} else {
+				bus.Publish(event.Event{Type: event.WorktreeRemoved, Task: t.Slug})
 			}
 		}
 	}
 
 	// ── 10. Summary ────────────────────────────────────────────────────────
-	printSummary(results)
+	p.summary(results)
+	run := buildReport(cfg, started, cancelled, tasks, entries, loopResults, statuses, outputs)
+	writeReports(cfg, p, run)
+	bus.Publish(event.Event{Type: event.RunFinished, Message: fmt.Sprintf("%d succeeded, %d failed", run.Succeeded, run.Failed)})
 
-	// Exit non-zero if any task failed (CI-compatible)
-	for _, r := range results {
-		if !r.Success {
-			os.Exit(1)
-		}
+	if cancelled {
+		return run, fmt.Errorf("run cancelled")
 	}
+	return run, nil
+}
 
-	return nil
+// keptWorktrees returns the tasks whose worktrees cleanup keeps, with the
+// reason: cancelled tasks when KeepOnCancel is on ('mochi resume'), and when
+// KeepFailed is on failed and skipped tasks plus the prerequisites of every
+// such task in the run, whose branches a retry merges ('mochi retry').
+func keptWorktrees(cfg config.Config, wm *worktree.Manager, tasks []parser.Task, statuses []string) map[string]string {
+	kept := make(map[string]string)
+	retryable := func(status string) bool { return status == "failed" || status == "skipped" }
+	for i, t := range tasks {
+		switch {
+		case statuses[i] == "cancelled" && cfg.KeepOnCancel:
+			kept[t.Slug] = "run 'mochi resume' to continue"
+		case retryable(statuses[i]) && cfg.KeepFailed:
+			kept[t.Slug] = fmt.Sprintf("run 'mochi retry %s' to try again", t.Slug)
+		}
+	}
+	if !cfg.KeepFailed {
+		return kept
+	}
+	// The manifest also holds the run's tasks left over from earlier
+	// attempts, when this is a retry.
+	entries, _ := wm.Entries()
+	for _, e := range worktree.RunEntries(entries, wm.RunID) {
+		if !retryable(e.Status) || e.Task == nil {
+			continue
+		}
+		for _, dep := range e.Task.DependsOn {
+			if kept[dep] == "" {
+				kept[dep] = "needed by " + e.Slug
+			}
+		}
+	}
+	return kept
 }
 
 // loopEnabled returns true when the Ralph Loop should run more than once
 // or when a reviewer is configured.
 func loopEnabled(cfg config.Config) bool {
-	return cfg.ReviewerModel != "" || cfg.MaxIterations > 1
+	return len(cfg.Reviewers()) > 0 || cfg.MaxIterations > 1
 }
 
 // runRalphLoop executes the worker (and optionally reviewer) loop for a single task.
 // With default config (MaxIterations=1, no ReviewerModel) it behaves identically to
 // the previous single-pass agent.Invoke call.
-func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) LoopResult {
+//
+// The loop starts at entry.Iteration when it is set, so a resumed task re-enters
+// the iteration that was interrupted instead of starting over.
+func runRalphLoop(ctx context.Context, cfg config.Config, p *printer, providers *agent.Registry, wm *worktree.Manager, task parser.Task, entry *worktree.Entry, tr *tracker, lessons *learnings.Store, bus *event.Bus) LoopResult {
 	maxIter := cfg.MaxIterations
 	if maxIter < 1 {
 		maxIter = 1
 	}
+	startIter := 1
+	if entry.Iteration > startIter {
+		startIter = min(entry.Iteration, maxIter)
+	}
 
 	var lastResult agent.Result
 	var lastMemCtx memory.Context
 	iterations := 0
 
-	for iter := 1; iter <= maxIter; iter++ {
+	verifyCmds := append(append([]string(nil), cfg.Verify...), task.Verify...)
+
+	// The reviewer diffs the task branch against where its own work began.
+	base := entry.Base
+	if base == "" {
+		base = cfg.BaseBranch
+	}
+	// Report modes change no files; the reviewer judges the report itself.
+	outputInstructions := output.Instructions(output.Mode(cfg.OutputMode))
+	if outputInstructions != "" {
+		base = ""
+	}
+
+	reviewers := cfg.Reviewers()
+	policy, _ := reviewer.ParsePolicy(cfg.ReviewPolicy) // validated in Run/Resume
+
+	fullTaskContext := task.Text()
+
+	var relevant []string
+	for _, l := range lessons.Relevant(fullTaskContext, cfg.LearningsLimit) {
+		relevant = append(relevant, l.Text)
+	}
+	if cfg.Verbose && len(relevant) > 0 {
+		p.info(fmt.Sprintf("  [learnings] %s: %d from earlier runs", task.Slug, len(relevant)))
+	}
+
+	for iter := startIter; iter <= maxIter; iter++ {
 		iterations = iter
+		_ = wm.Update(task.Slug, func(e *worktree.Entry) { e.Iteration = iter })
+		tr.iteration(task.Slug, iter, agent.LogPath(cfg.LogDir, task.Slug, iter, maxIter))
+		bus.Publish(event.Event{Type: event.IterationStarted, Task: task.Slug, Iteration: iter, Model: task.Model})
 
 		// Load memory from previous iteration (empty on first pass)
 		memCtx := memory.Load(entry.Path)
 		lastMemCtx = memCtx
 
 		if cfg.Verbose && loopEnabled(cfg) {
-			printInfo(fmt.Sprintf("  [loop] %s iteration %d/%d", task.Slug, iter, maxIter))
-		}
-
-		fullTaskContext := task.Title
-		if task.Description != "" {
-			fullTaskContext += "\n\n" + task.Description
+			p.info(fmt.Sprintf("  [loop] %s iteration %d/%d", task.Slug, iter, maxIter))
 		}
 
 		// Run worker agent
-		result := agent.Invoke(agent.InvokeOptions{
+		result := agent.Invoke(ctx, agent.InvokeOptions{
 			WorktreePath:  entry.Path,
 			Task:          fullTaskContext,
 			Model:         task.Model,
@@ -364,8 +909,29 @@ func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) Lo
 			Iteration:     iter,
 			MaxIterations: maxIter,
 			MemoryContext: memCtx,
+			Learnings:     relevant,
+			Providers:     providers,
+
+			OutputInstructions: outputInstructions,
 		}, task.Slug)
-		lastResult = result
+		for _, l := range learnings.FromOutput(result.Output) {
+			lessons.Add(learnings.SourceAgent, task.Slug, l)
+		}
+
+		// Run verification commands. Failures skip the reviewer, are fed back
+		// to the next iteration, and fail the task if they persist to the end.
+		verified := true
+		verification := ""
+		if result.Success && len(verifyCmds) > 0 {
+			result.Verification = verify.Run(ctx, entry.Path, verifyCmds, time.Duration(cfg.Timeout)*time.Second)
+			appendVerifyLog(result.LogPath, result.Verification)
+			verified = verify.Passed(result.Verification)
+			verification = verify.Summary(result.Verification)
+			if !verified {
+				p.warn(fmt.Sprintf("%s iter %d: verification failed (%s)", task.Slug, iter, strings.Join(verify.Failed(result.Verification), ", ")))
+			}
+			bus.Publish(verificationEvent(task.Slug, iter, result.Verification))
+		}
 
 		// Determine status for memory write
 		status := "in-progress"
@@ -374,34 +940,78 @@ func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) Lo
 		}
 
 		reviewerNotes := ""
+		reviewJSON := ""
 		done := false
 
-		// Run reviewer if configured and worker succeeded
-		if cfg.ReviewerModel != "" && result.Success {
-			decision, err := reviewer.Review(reviewer.Options{
+		// Run the reviewers if configured and the worker succeeded
+		if len(reviewers) > 0 && result.Success && verified {
+			votes := reviewer.ReviewAll(ctx, reviewer.Options{
 				WorktreePath: entry.Path,
+				Slug:         task.Slug,
 				Task:         fullTaskContext,
-				Model:        cfg.ReviewerModel,
 				WorkerOutput: result.Output,
 				Iteration:    iter,
 				MaxIter:      maxIter,
 				Timeout:      cfg.Timeout,
 				Verbose:      cfg.Verbose,
 				LogDir:       cfg.LogDir,
-			})
+				Providers:    providers,
+				Base:         base,
+				Diff: reviewer.DiffOptions{
+					MaxBytes: cfg.ReviewDiffLimit,
+					Exclude:  cfg.ReviewExclude,
+				},
+				Verification: result.Verification,
+				Format:       cfg.ReviewFormat,
+				Rubric:       cfg.ReviewRubric,
+			}, reviewers)
+			if len(reviewers) > 1 {
+				for _, v := range votes {
+					if v.Err != nil {
+						p.warn(fmt.Sprintf("reviewer %s failed for %s iter %d: %v", v.Model, task.Slug, iter, v.Err))
+					}
+				}
+			}
+			recordReviewLearnings(lessons, task.Slug, votes)
+			decision, err := reviewer.Combine(policy, votes)
 			if err != nil {
-				printWarn(fmt.Sprintf("reviewer error for %s iter %d: %v", task.Slug, iter, err))
+				p.warn(fmt.Sprintf("reviewer error for %s iter %d: %v", task.Slug, iter, err))
 			} else {
 				reviewerNotes = decision.Feedback
+				if decision.Verdict != nil {
+					if b, err := json.MarshalIndent(decision.Verdict, "", "  "); err == nil {
+						reviewJSON = string(b)
+					}
+				}
 				done = decision.Done
+				verdict := event.Event{Type: event.ReviewVerdict, Task: task.Slug, Iteration: iter, Status: "retry", Message: reviewerNotes}
+				if done {
+					verdict.Status = "done"
+				}
+				bus.Publish(verdict)
+			}
+		}
+
+		// A cancellation that lands after the worker finished (during
+		// verification or review) still leaves the task unfinished.
+		if ctx.Err() != nil {
+			if result.Success {
+				result.Success = false
+				result.Error = fmt.Errorf("cancelled: %w", context.Canceled)
 			}
+			status = "cancelled"
 		}
 
-		if result.Success && cfg.ReviewerModel == "" {
+		if result.Success && verified && len(reviewers) == 0 {
 			done = true
 		}
 		if !result.Success {
-			done = true // stop on agent failure
+			done = true // stop on agent failure or cancellation
+		}
+		if result.Success && !verified && iter == maxIter {
+			result.Success = false
+			result.Error = fmt.Errorf("verification failed: %s", strings.Join(verify.Failed(result.Verification), ", "))
+			status = "failed"
 		}
 
 		if done || iter == maxIter {
@@ -409,6 +1019,7 @@ func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) Lo
 				status = "done"
 			}
 		}
+		lastResult = result
 
 		// Write memory files after each iteration
 		_ = memory.Write(entry.Path, memory.IterationData{
@@ -416,8 +1027,17 @@ func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) Lo
 			Task:          fullTaskContext,
 			WorkerOutput:  result.Output,
 			ReviewerNotes: reviewerNotes,
+			Review:        reviewJSON,
+			Verification:  verification,
 			Status:        status,
 		})
+		bus.Publish(event.Event{Type: event.IterationFinished, Task: task.Slug, Iteration: iter, Model: task.Model, Status: status})
+
+		// Fold iterations that left the recent window into the digest
+		// before the next pass reads it.
+		if !done && iter < maxIter && cfg.SummaryModel != "" {
+			compactMemory(ctx, cfg, p, providers, task.Slug, entry.Path)
+		}
 
 		// Reload memory context so LoopResult reflects latest state
 		lastMemCtx = memory.Load(entry.Path)
@@ -436,9 +1056,65 @@ func runRalphLoop(cfg config.Config, task parser.Task, entry *worktree.Entry) Lo
 
 // ── Helpers ────────────────────────────────────────────────────────────────
 
-func resolveTaskFile(cfg config.Config) (path string, cleanup func(), err error) {
+// compactMemory summarizes a task's older iterations with cfg.SummaryModel.
+// A failure is only a warning: the history then falls back to one line per
+// older iteration.
+func compactMemory(ctx context.Context, cfg config.Config, p *printer, providers *agent.Registry, slug, worktreePath string) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	compacted, err := memory.Compact(worktreePath, func(prompt string) (string, error) {
+		return agent.Complete(ctx, providers, cfg.SummaryModel, prompt)
+	})
+	if err != nil {
+		p.warn(fmt.Sprintf("cannot summarize memory for %s: %v", slug, err))
+		return
+	}
+	if compacted && cfg.Verbose {
+		p.info(fmt.Sprintf("  [memory] %s: older iterations summarized by %s", slug, cfg.SummaryModel))
+	}
+}
+
+// recordReviewLearnings stores the issues raised by reviewers that sent the
+// work back: the blocking issues of a structured verdict, or the items of
+// free-text feedback.
+func recordReviewLearnings(lessons *learnings.Store, slug string, votes []reviewer.Vote) {
+	for _, v := range votes {
+		if v.Err != nil || v.Decision.Done {
+			continue
+		}
+		issues := learnings.FromFeedback(v.Decision.Feedback)
+		if v.Decision.Verdict != nil {
+			issues = v.Decision.Verdict.Blocking
+		}
+		for _, issue := range issues {
+			lessons.Add(learnings.SourceReviewer, slug, issue)
+		}
+	}
+}
+
+// appendVerifyLog records verification results at the end of an agent log.
+func appendVerifyLog(logPath string, results []verify.Result) {
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	verify.WriteLog(f, results)
+}
+
+// failureReason describes why a task's final result did not succeed.
+func failureReason(r agent.Result) string {
+	if errors.Is(r.Error, context.Canceled) {
+		return "cancelled"
+	}
+	if len(r.Verification) > 0 && !verify.Passed(r.Verification) {
+		return "verification failed"
+	}
+	return "agent failed"
+}
+
+func resolveTaskFile(cfg config.Config, repoRoot string) (path string, cleanup func(), err error) {
 	if cfg.IssueNumber > 0 {
-		repoRoot, _ := os.Getwd()
 		tmp, fetchErr := gh.FetchIssueTasks(cfg.IssueNumber, repoRoot)
 		if fetchErr != nil {
 			return "", nil, fmt.Errorf("failed to fetch GitHub issue #%d: %w", cfg.IssueNumber, fetchErr)
@@ -448,14 +1124,14 @@ func resolveTaskFile(cfg config.Config) (path string, cleanup func(), err error)
 
 	// Auto-detect common task file names if the default is missing
 	if cfg.InputFile == "PRD.md" {
-		if _, err := os.Stat(cfg.InputFile); os.IsNotExist(err) {
+		if _, err := os.Stat(inRepo(repoRoot, cfg.InputFile)); os.IsNotExist(err) {
 			candidates := []string{
 				"PLAN.md", "plan.md", "input.md", "tasks.md",
 				"docs/PLAN.md", "docs/PRD.md", "examples/PRD.md",
 			}
 			for _, c := range candidates {
-				if _, err := os.Stat(c); err == nil {
-					return c, nil, nil
+				if _, err := os.Stat(inRepo(repoRoot, c)); err == nil {
+					return inRepo(repoRoot, c), nil, nil
 				}
 			}
 		}
@@ -465,7 +1141,7 @@ func resolveTaskFile(cfg config.Config) (path string, cleanup func(), err error)
 		return "", nil, fmt.Errorf("no task file specified — use --input <path>")
 	}
 
-	return cfg.InputFile, nil, nil
+	return inRepo(repoRoot, cfg.InputFile), nil, nil
 }
 
 func filterBySlug(tasks []parser.Task, slug string) []parser.Task {
@@ -477,6 +1153,41 @@ func filterBySlug(tasks []parser.Task, slug string) []parser.Task {
 	return nil
 }
 
+// dropMissingDependencies removes DependsOn entries that refer to tasks outside
+// the given set (e.g. after --task filtering) and returns them as "task → dep".
+func dropMissingDependencies(tasks []parser.Task) []string {
+	present := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.Slug] = true
+	}
+
+	var dropped []string
+	for i := range tasks {
+		var kept []string
+		for _, dep := range tasks[i].DependsOn {
+			if present[dep] {
+				kept = append(kept, dep)
+			} else {
+				dropped = append(dropped, fmt.Sprintf("%s → %s", tasks[i].Slug, dep))
+			}
+		}
+		tasks[i].DependsOn = kept
+	}
+	return dropped
+}
+
+// failedPrerequisite returns the slug of the first prerequisite of task that did
+// not succeed, or "" when all of them did. Callers must only invoke it once every
+// prerequisite has finished.
+func failedPrerequisite(task parser.Task, index map[string]int, results []agent.Result) string {
+	for _, dep := range task.DependsOn {
+		if !results[index[dep]].Success {
+			return dep
+		}
+	}
+	return ""
+}
+
 func slugList(tasks []parser.Task) string {
 	parts := make([]string, len(tasks))
 	for i, t := range tasks {
@@ -499,32 +1210,100 @@ func statusStr(success bool) string {
 	return "failed"
 }
 
-func printDryRun(tasks []parser.Task, cfg config.Config) error {
-	fmt.Println(yellow("\n[MOCHI DRY RUN] The following would be executed:\n"))
+// ── Terminal styles (Lipgloss) ─────────────────────────────────────────────
+
+var (
+	styleRed    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+	styleGreen  = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+	styleYellow = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C"))
+	styleBold   = lipgloss.NewStyle().Bold(true)
+)
+
+func red(s string) string    { return styleRed.Render(s) }
+func green(s string) string  { return styleGreen.Render(s) }
+func yellow(s string) string { return styleYellow.Render(s) }
+func bold(s string) string   { return styleBold.Render(s) }
+
+// printer writes a run's progress lines. Task goroutines and hook callbacks
+// share it, so every line is written under its lock.
+type printer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *printer) printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, format, args...)
+}
+
+// setWriter directs later lines to w and returns the previous writer. The
+// dashboard swaps in io.Discard while it owns the terminal.
+func (p *printer) setWriter(w io.Writer) io.Writer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev := p.w
+	p.w = w
+	return prev
+}
+
+func (p *printer) section(s string) {
+	p.printf("\n%s %s\n", bold("[MOCHI]"), s)
+}
+
+func (p *printer) info(s string) {
+	p.printf("  %s\n", s)
+}
+
+func (p *printer) success(s string) {
+	p.printf("  %s %s\n", green("✓"), s)
+}
+
+func (p *printer) fail(s string) {
+	p.printf("  %s %s\n", red("✗"), s)
+}
+
+func (p *printer) warn(s string) {
+	p.printf("  %s %s\n", yellow("⚠"), s)
+}
+
+func (p *printer) dryRun(tasks []parser.Task, cfg config.Config) error {
+	p.printf("%s\n", yellow("\n[MOCHI DRY RUN] The following would be executed:\n"))
 
 	if cfg.MaxWorktrees > 0 {
-		fmt.Printf("  Max concurrent worktrees: %d\n\n", cfg.MaxWorktrees)
+		p.printf("  Max concurrent worktrees: %d\n\n", cfg.MaxWorktrees)
 	}
 	if cfg.Workspace != "" {
-		fmt.Printf("  Workspace mode: %s\n\n", cfg.Workspace)
+		p.printf("  Workspace mode: %s\n\n", cfg.Workspace)
+	}
+	if summary := setupSummary(cfg.Setup); summary != "" {
+		p.printf("  Worktree setup: %s\n\n", summary)
 	}
 
 	for i, t := range tasks {
-		fmt.Printf("  Task %d: %q\n", i+1, t.Title)
-		fmt.Printf("    Branch:      %s/%s\n", cfg.BranchPrefix, t.Slug)
-		fmt.Printf("    Worktree:    %s/%s\n", cfg.WorktreeDir, t.Slug)
-		fmt.Printf("    Model:       %s\n", t.Model)
-		fmt.Printf("    Log:         %s/%s.log\n", cfg.LogDir, t.Slug)
-		if cfg.ReviewerModel != "" {
-			fmt.Printf("    Reviewer:    %s (max %d iterations)\n", cfg.ReviewerModel, cfg.MaxIterations)
+		p.printf("  Task %d: %q\n", i+1, t.Title)
+		p.printf("    Branch:      %s/%s\n", cfg.BranchPrefix, t.Slug)
+		p.printf("    Worktree:    %s/%s\n", cfg.WorktreeDir, t.Slug)
+		p.printf("    Model:       %s\n", t.Model)
+		if len(t.DependsOn) > 0 {
+			p.printf("    Depends on:  %s\n", strings.Join(t.DependsOn, ", "))
+		}
+		p.printf("    Log:         %s/%s.log\n", cfg.LogDir, t.Slug)
+		if verifyCmds := append(append([]string(nil), cfg.Verify...), t.Verify...); len(verifyCmds) > 0 {
+			p.printf("    Verify:      %s\n", strings.Join(verifyCmds, " && "))
+		}
+		if reviewers := cfg.Reviewers(); len(reviewers) > 1 {
+			p.printf("    Reviewers:   %s, %s consensus (max %d iterations)\n", strings.Join(reviewers, ", "), cfg.ReviewPolicy, cfg.MaxIterations)
+		} else if len(reviewers) == 1 {
+			p.printf("    Reviewer:    %s (max %d iterations)\n", reviewers[0], cfg.MaxIterations)
 		}
-		fmt.Printf("    Output mode: %s\n\n", cfg.OutputMode)
+		p.printf("    Output mode: %s\n\n", cfg.OutputMode)
 	}
-	fmt.Println(yellow("No changes made."))
+	p.printf("%s\n", yellow("No changes made."))
 	return nil
 }
 
-func printSummary(results []agent.Result) {
+func (p *printer) summary(results []agent.Result) {
 	succeeded, failed := 0, 0
 	for _, r := range results {
 		if r.Success {
@@ -533,60 +1312,29 @@ func printSummary(results []agent.Result) {
 			failed++
 		}
 	}
-	fmt.Println()
-	fmt.Println(bold("─────────────────────────────────────────────────"))
 	line := fmt.Sprintf("[MOCHI] Run complete: %d succeeded, %d failed", succeeded, failed)
 	if failed == 0 {
-		fmt.Println(green(line))
+		line = green(line)
 	} else {
-		fmt.Println(red(line))
+		line = red(line)
 	}
-	fmt.Println(bold("─────────────────────────────────────────────────"))
+	rule := bold("─────────────────────────────────────────────────")
+	p.printf("\n%s\n%s\n%s\n", rule, line, rule)
 }
 
-func printLoopResult(lr LoopResult) {
+func (p *printer) loopResult(lr LoopResult) {
 	r := lr.FinalWorkerResult
 	if r.Success {
 		if lr.Iterations > 1 {
-			printSuccess(fmt.Sprintf("%-30s done  (%.0fs, %d iterations)", r.Slug, r.Duration.Seconds(), lr.Iterations))
+			p.success(fmt.Sprintf("%-30s done  (%.0fs, %d iterations)", r.Slug, r.Duration.Seconds(), lr.Iterations))
 		} else {
-			printSuccess(fmt.Sprintf("%-30s done  (%.0fs)", r.Slug, r.Duration.Seconds()))
+			p.success(fmt.Sprintf("%-30s done  (%.0fs)", r.Slug, r.Duration.Seconds()))
 		}
+	} else if errors.Is(r.Error, context.Canceled) {
+		p.warn(fmt.Sprintf("%-30s cancelled", r.Slug))
+	} else if r.LogPath == "" {
+		p.fail(fmt.Sprintf("%-30s FAILED — %v", r.Slug, r.Error))
 	} else {
-		printFail(fmt.Sprintf("%-30s FAILED (%.0fs) — see %s", r.Slug, r.Duration.Seconds(), r.LogPath))
+		p.fail(fmt.Sprintf("%-30s FAILED (%.0fs) — see %s", r.Slug, r.Duration.Seconds(), r.LogPath))
 	}
 }
-
-// ── Terminal styles (Lipgloss) ─────────────────────────────────────────────
-
-var (
-	styleRed    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
-	styleGreen  = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
-	styleYellow = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C"))
-	styleBold   = lipgloss.NewStyle().Bold(true)
-)
-
-func red(s string) string    { return styleRed.Render(s) }
-func green(s string) string  { return styleGreen.Render(s) }
-func yellow(s string) string { return styleYellow.Render(s) }
-func bold(s string) string   { return styleBold.Render(s) }
-
-func printSection(s string) {
-	fmt.Printf("\n%s %s\n", bold("[MOCHI]"), s)
-}
-
-func printInfo(s string) {
-	fmt.Printf("  %s\n", s)
-}
-
-func printSuccess(s string) {
-	fmt.Printf("  %s %s\n", green("✓"), s)
-}
-
-func printFail(s string) {
-	fmt.Printf("  %s %s\n", red("✗"), s)
-}
-
-func printWarn(s string) {
-	fmt.Printf("  %s %s\n", yellow("⚠"), s)
-}
@@ -0,0 +1,372 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+func TestFailedPrerequisite(t *testing.T) {
+	index := map[string]int{"api": 0, "db": 1, "ui": 2}
+	tests := []struct {
+		name    string
+		deps    []string
+		success []bool
+		want    string
+	}{
+		{"no prerequisites", nil, []bool{false, false, false}, ""},
+		{"all succeeded", []string{"api", "db"}, []bool{true, true, false}, ""},
+		{"one failed", []string{"api", "db"}, []bool{true, false, false}, "db"},
+		{"first failure wins", []string{"db", "api"}, []bool{false, false, false}, "db"},
+	}
+	for _, tt := range tests {
+		results := make([]agent.Result, len(tt.success))
+		for i, ok := range tt.success {
+			results[i].Success = ok
+		}
+		task := parser.Task{Slug: "ui", DependsOn: tt.deps}
+		if got := failedPrerequisite(task, index, results); got != tt.want {
+			t.Errorf("%s: failedPrerequisite = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDropMissingDependencies(t *testing.T) {
+	tests := []struct {
+		name        string
+		tasks       []parser.Task
+		wantDeps    [][]string
+		wantDropped []string
+	}{
+		{
+			name:     "all present",
+			tasks:    []parser.Task{{Slug: "api"}, {Slug: "ui", DependsOn: []string{"api"}}},
+			wantDeps: [][]string{nil, {"api"}},
+		},
+		{
+			name:        "filtered out",
+			tasks:       []parser.Task{{Slug: "ui", DependsOn: []string{"api", "db"}}},
+			wantDeps:    [][]string{nil},
+			wantDropped: []string{"ui → api", "ui → db"},
+		},
+		{
+			name:        "partly present",
+			tasks:       []parser.Task{{Slug: "db"}, {Slug: "ui", DependsOn: []string{"api", "db"}}},
+			wantDeps:    [][]string{nil, {"db"}},
+			wantDropped: []string{"ui → api"},
+		},
+	}
+	for _, tt := range tests {
+		dropped := dropMissingDependencies(tt.tasks)
+		if !reflect.DeepEqual(dropped, tt.wantDropped) {
+			t.Errorf("%s: dropped = %v; want %v", tt.name, dropped, tt.wantDropped)
+		}
+		for i, task := range tt.tasks {
+			if !reflect.DeepEqual(task.DependsOn, tt.wantDeps[i]) {
+				t.Errorf("%s: %s.DependsOn = %v; want %v", tt.name, task.Slug, task.DependsOn, tt.wantDeps[i])
+			}
+		}
+	}
+}
+
+// setupRun creates a repository and a provider registry whose fake agent
+// commits a file named after its worktree, fails for tasks mentioning
+// "broken" and blocks for tasks mentioning "slow".
+func setupRun(t *testing.T) (string, *agent.Registry) {
+	t.Helper()
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	script := filepath.Join(t.TempDir(), "agent.sh")
+	if err := os.WriteFile(script, []byte(`#!/bin/sh
+grep -q broken "$1" && exit 1
+grep -q slow "$1" && exec sleep 30
+name="$(basename "$PWD").txt"
+echo done > "$name" && git add "$name" && git commit -qm work
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	p, err := agent.NewTemplateProvider(agent.TemplateSpec{Name: "fake", Match: "^fake-", Command: script + " {{.PromptFile}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, agent.NewRegistry(p)
+}
+
+func TestExecute_Statuses(t *testing.T) {
+	task := func(slug string, deps ...string) parser.Task {
+		return parser.Task{Slug: slug, Title: strings.ReplaceAll(slug, "-", " "), Model: "fake-1", DependsOn: deps}
+	}
+	tests := []struct {
+		name      string
+		tasks     []parser.Task
+		done      []string // already done in an earlier run (resume)
+		setup     []config.SetupCommand
+		cancel    time.Duration // cancel the run after this long; -1 before it starts
+		want      []string
+		cancelled bool
+	}{
+		{
+			name:  "chain succeeds",
+			tasks: []parser.Task{task("add-api"), task("add-ui", "add-api")},
+			want:  []string{"done", "done"},
+		},
+		{
+			name:  "failed prerequisite skips dependents",
+			tasks: []parser.Task{task("broken-api"), task("add-ui", "broken-api"), task("add-docs", "add-ui"), task("add-db")},
+			want:  []string{"failed", "skipped", "skipped", "done"},
+		},
+		{
+			name:  "done tasks are not run again",
+			tasks: []parser.Task{task("broken-api"), task("add-ui", "broken-api")},
+			done:  []string{"broken-api"},
+			want:  []string{"done", "done"},
+		},
+		{
+			name:  "setup failure fails the task",
+			tasks: []parser.Task{task("add-api"), task("add-ui", "add-api")},
+			setup: []config.SetupCommand{{Run: "exit 3"}},
+			want:  []string{"failed", "skipped"},
+		},
+		{
+			name:      "cancelled before start",
+			tasks:     []parser.Task{task("add-api"), task("add-ui", "add-api")},
+			cancel:    -1,
+			want:      []string{"cancelled", "cancelled"},
+			cancelled: true,
+		},
+		{
+			name:      "cancelled while running",
+			tasks:     []parser.Task{task("slow-api"), task("add-ui", "slow-api")},
+			cancel:    300 * time.Millisecond,
+			want:      []string{"cancelled", "cancelled"},
+			cancelled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, providers := setupRun(t)
+			cfg := config.Default()
+			cfg.Learnings = false
+			cfg.Timeout = 30
+			cfg.LogDir = t.TempDir()
+			cfg.RunID = "test"
+			cfg.KeepWorktrees = true
+			cfg.Setup.Commands = tt.setup
+
+			wm := worktree.NewManager(repo, "main", "feature", filepath.Join(repo, ".worktrees"))
+			wm.RunID = cfg.RunID
+			entries := make([]*worktree.Entry, len(tt.tasks))
+			for i, task := range tt.tasks {
+				e, err := wm.Create(task.Slug)
+				if err != nil {
+					t.Fatalf("Create(%s): %v", task.Slug, err)
+				}
+				for _, slug := range tt.done {
+					if slug == task.Slug {
+						e.Status = "done"
+					}
+				}
+				entries[i] = e
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel < 0 {
+				cancel()
+			} else if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			run, err := execute(ctx, cfg, &printer{w: io.Discard}, providers, &event.Bus{}, wm, repo, tt.tasks, entries)
+			if tt.cancelled != (err != nil) || run.Cancelled != tt.cancelled {
+				t.Fatalf("execute error = %v, cancelled = %v; want cancelled %v", err, run.Cancelled, tt.cancelled)
+			}
+
+			manifest, err := wm.Entries()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, rt := range run.Tasks {
+				if rt.Status != tt.want[i] {
+					t.Errorf("%s: status = %q (%s); want %q", rt.Slug, rt.Status, rt.Error, tt.want[i])
+				}
+				if e := worktree.FindEntry(manifest, rt.Slug, cfg.RunID); len(tt.done) == 0 && e.Status != tt.want[i] {
+					t.Errorf("%s: manifest status = %q; want %q", rt.Slug, e.Status, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExecute_MergesPrerequisites(t *testing.T) {
+	repo, providers := setupRun(t)
+	cfg := config.Default()
+	cfg.Learnings = false
+	cfg.Timeout = 30
+	cfg.LogDir = t.TempDir()
+	cfg.KeepWorktrees = true
+
+	wm := worktree.NewManager(repo, "main", "feature", filepath.Join(repo, ".worktrees"))
+	tasks := []parser.Task{
+		{Slug: "add-api", Title: "add api", Model: "fake-1"},
+		{Slug: "add-ui", Title: "add ui", Model: "fake-1", DependsOn: []string{"add-api"}},
+	}
+	entries := make([]*worktree.Entry, len(tasks))
+	for i, task := range tasks {
+		e, err := wm.Create(task.Slug)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i] = e
+	}
+	if _, err := execute(context.Background(), cfg, &printer{w: io.Discard}, providers, &event.Bus{}, wm, repo, tasks, entries); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(entries[1].Path, "add-api.txt")); err != nil {
+		t.Errorf("dependent worktree lacks its prerequisite's work: %v", err)
+	}
+}
+
+func TestKeptWorktrees(t *testing.T) {
+	tasks := []parser.Task{{Slug: "api"}, {Slug: "ui", DependsOn: []string{"api"}}, {Slug: "docs"}}
+	tests := []struct {
+		name         string
+		keepFailed   bool
+		keepOnCancel bool
+		statuses     []string
+		want         []string
+	}{
+		{"nothing kept", false, false, []string{"done", "failed", "cancelled"}, nil},
+		{"cancelled", false, true, []string{"done", "done", "cancelled"}, []string{"docs"}},
+		{"failed and skipped", true, false, []string{"failed", "skipped", "cancelled"}, []string{"api", "ui"}},
+		{"prerequisite of a failed task", true, true, []string{"done", "failed", "done"}, []string{"api", "ui"}},
+	}
+	for _, tt := range tests {
+		repo, _ := setupRun(t)
+		wm := worktree.NewManager(repo, "main", "feature", filepath.Join(repo, ".worktrees"))
+		wm.RunID = "test"
+		for i, task := range tasks {
+			if _, err := wm.Create(task.Slug); err != nil {
+				t.Fatal(err)
+			}
+			task := task
+			if err := wm.Update(task.Slug, func(e *worktree.Entry) { e.Status, e.Task = tt.statuses[i], &task }); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cfg := config.Default()
+		cfg.KeepFailed, cfg.KeepOnCancel = tt.keepFailed, tt.keepOnCancel
+
+		var got []string
+		kept := keptWorktrees(cfg, wm, tasks, tt.statuses)
+		for _, task := range tasks {
+			if kept[task.Slug] != "" {
+				got = append(got, task.Slug)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: kept %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTaskControl(t *testing.T) {
+	tasks := []parser.Task{{Slug: "api"}, {Slug: "ui"}}
+	run, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+	c := newTaskControl(run, cancelRun, tasks)
+
+	c.Cancel("api")
+	if !errors.Is(c.taskCtx("api").Err(), context.Canceled) {
+		t.Error("Cancel(api) did not cancel its context")
+	}
+	if c.taskCtx("ui").Err() != nil || run.Err() != nil {
+		t.Error("Cancel(api) cancelled more than api")
+	}
+
+	// A re-run gets a fresh context and goes through the hook.
+	var rerun []string
+	c.rerun = func(slug string) {
+		c.reset(slug)
+		rerun = append(rerun, slug)
+	}
+	c.Rerun("api")
+	if c.taskCtx("api").Err() != nil || !reflect.DeepEqual(rerun, []string{"api"}) {
+		t.Errorf("Rerun(api): ctx err = %v, hook calls = %v", c.taskCtx("api").Err(), rerun)
+	}
+
+	// ctrl+c on the dashboard cancels the run itself, so execute treats it
+	// as cancelled, not just every task.
+	c.CancelAll()
+	if run.Err() == nil {
+		t.Error("CancelAll did not cancel the run")
+	}
+	for _, task := range tasks {
+		if c.taskCtx(task.Slug).Err() == nil {
+			t.Errorf("CancelAll left %s running", task.Slug)
+		}
+	}
+	if c.reset("ui").Err() == nil {
+		t.Error("a task reset after CancelAll is not cancelled")
+	}
+}
+
+func TestTracker_Status(t *testing.T) {
+	tasks := []parser.Task{{Slug: "api", Model: "fake-1"}}
+	tr, initial := newTracker(tasks, 3, []string{"pending"})
+	if initial[0].Status != "pending" || initial[0].MaxIterations != 3 {
+		t.Fatalf("initial = %+v", initial[0])
+	}
+
+	tests := []struct {
+		status       string
+		started      bool
+		finished     bool
+		wantIterZero bool
+	}{
+		{"running", true, false, false},
+		{"failed", true, true, false},
+		{"pending", false, false, true}, // re-run from the dashboard
+		{"running", true, false, false},
+		{"done", true, true, false},
+	}
+	for _, tt := range tests {
+		tr.iteration("api", 2, "log")
+		tr.status("api", tt.status)
+		s := *tr.states["api"]
+		if s.Status != tt.status || !s.Started.IsZero() != tt.started || !s.Finished.IsZero() != tt.finished {
+			t.Errorf("after %s: %+v", tt.status, s)
+		}
+		if tt.wantIterZero != (s.Iteration == 0) {
+			t.Errorf("after %s: iteration = %d", tt.status, s.Iteration)
+		}
+	}
+
+	var none *tracker
+	none.status("api", "running") // a nil tracker is a no-op
+}
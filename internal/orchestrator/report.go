@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/report"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+// buildReport assembles the run record written by --report-json and
+// --report-junit. outputs may be nil when no output mode ran.
+func buildReport(cfg config.Config, started time.Time, cancelled bool, tasks []parser.Task, entries []*worktree.Entry, loopResults []LoopResult, statuses, outputs []string) report.Run {
+	run := report.Run{RunID: cfg.RunID, Started: started, Cancelled: cancelled}
+	for i, t := range tasks {
+		lr := loopResults[i]
+		r := lr.FinalWorkerResult
+		rt := report.Task{
+			Slug:         t.Slug,
+			Title:        t.Title,
+			Model:        t.Model,
+			Status:       statuses[i],
+			Success:      r.Success,
+			Iterations:   lr.Iterations,
+			Duration:     lr.Duration.Seconds(),
+			LogPath:      r.LogPath,
+			Branch:       entries[i].Branch,
+			PRURL:        entries[i].PRURL,
+			Verification: r.Verification,
+		}
+		if rt.Status == "" {
+			rt.Status = statusStr(r.Success)
+		}
+		if r.Error != nil {
+			rt.Error = r.Error.Error()
+		} else if !r.Success {
+			rt.Error = failureReason(r)
+		}
+		if rt.LogPath == "" && lr.Iterations > 0 {
+			rt.LogPath = agent.LogPath(cfg.LogDir, t.Slug, lr.Iterations, cfg.MaxIterations)
+		}
+		if outputs != nil {
+			rt.Output = outputs[i]
+		}
+		mem := lr.FinalMemory
+		if mem.Feedback != "" || mem.Review != "" {
+			rt.Review = &report.Review{Feedback: mem.Feedback}
+			if json.Valid([]byte(mem.Review)) {
+				rt.Review.Verdict = json.RawMessage(mem.Review)
+			}
+		}
+		run.Tasks = append(run.Tasks, rt)
+	}
+	run.Finish(time.Now())
+	return run
+}
+
+// writeReports writes the run record to the configured report files. Failures
+// are reported but do not fail the run.
+func writeReports(cfg config.Config, p *printer, run report.Run) {
+	if cfg.ReportJSON != "" {
+		if err := report.WriteJSON(cfg.ReportJSON, run); err != nil {
+			p.warn(fmt.Sprintf("%v", err))
+		} else {
+			p.info(fmt.Sprintf("Run report written to %s", cfg.ReportJSON))
+		}
+	}
+	if cfg.ReportJUnit != "" {
+		if err := report.WriteJUnit(cfg.ReportJUnit, run); err != nil {
+			p.warn(fmt.Sprintf("%v", err))
+		} else {
+			p.info(fmt.Sprintf("JUnit report written to %s", cfg.ReportJUnit))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/report"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+// RetryOptions selects the task Retry runs again and what changes for the new
+// attempt.
+type RetryOptions struct {
+	Slug         string
+	Model        string // replaces the task's model when set
+	Instructions string // added to the task description when set
+}
+
+// Retry runs one task of an earlier run again. It reuses the task's worktree
+// and branch from the manifest — of cfg.RunID, or of the most recent run that
+// has the task — so the new attempt starts from the previous one's commits and
+// memory files. A task that was skipped first gets its prerequisites' branches
+// merged, so they must have succeeded by then. The Ralph Loop then runs from
+// iteration 1, followed by the configured output or PR step for that task
+// only; an open PR gets the new commits pushed to it.
+func Retry(ctx context.Context, cfg config.Config, opts Options, retry RetryOptions) (report.Run, error) {
+	p := opts.printer()
+	repoRoot, err := opts.setup(&cfg)
+	if err != nil {
+		return report.Run{}, err
+	}
+	providers, err := preflight(cfg)
+	if err != nil {
+		return report.Run{}, err
+	}
+	if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
+		return report.Run{}, fmt.Errorf("cannot create log dir %q: %w", cfg.LogDir, err)
+	}
+
+	wm := worktree.NewManager(repoRoot, cfg.BaseBranch, cfg.BranchPrefix, cfg.WorktreeDir)
+	manifest, err := wm.Entries()
+	if err != nil {
+		return report.Run{}, fmt.Errorf("cannot read worktree manifest: %w", err)
+	}
+	entry, err := retryEntry(manifest, retry.Slug, cfg.RunID)
+	if err != nil {
+		return report.Run{}, err
+	}
+	cfg.RunID = entry.RunID
+	wm.RunID = entry.RunID
+
+	// A task skipped because a prerequisite failed starts from its
+	// prerequisites' work once they have been retried.
+	if branches, err := retryPrerequisites(manifest, entry); err != nil {
+		return report.Run{}, err
+	} else if len(branches) > 0 {
+		if entry, err = wm.MergeBranches(entry.Slug, branches); err != nil {
+			return report.Run{}, err
+		}
+	}
+
+	// The recorded task stays as it was; the changes only apply to this
+	// attempt.
+	task := *entry.Task
+	if retry.Model != "" {
+		task.Model = retry.Model
+	}
+	if retry.Instructions != "" {
+		task.Description += "\n\n## Additional instructions for this retry\n\n" + retry.Instructions
+	}
+	task.DependsOn = nil
+
+	// The Ralph Loop resumes from entry.Iteration; a retry starts a fresh loop.
+	entry.Status = "pending"
+	entry.Iteration = 0
+	if err := wm.Update(task.Slug, func(e *worktree.Entry) {
+		e.Status = entry.Status
+		e.Iteration = 0
+	}); err != nil {
+		return report.Run{}, err
+	}
+
+	p.section(fmt.Sprintf("Retrying %s [%s] in %s (%s)", task.Slug, task.Model, entry.Path, entry.Branch))
+
+	bus, closeEvents, err := opts.events(cfg, repoRoot, p)
+	if err != nil {
+		return report.Run{}, err
+	}
+	defer closeEvents()
+	bus.Publish(event.Event{Type: event.RunStarted, Task: task.Slug, Model: task.Model, Message: "retry"})
+
+	return execute(ctx, cfg, p, providers, bus, wm, repoRoot, []parser.Task{task}, []*worktree.Entry{entry})
+}
+
+// retryEntry finds the manifest entry of the task to retry, in run or, when
+// run is empty, in the most recent run that has it.
+func retryEntry(manifest []*worktree.Entry, slug, run string) (*worktree.Entry, error) {
+	entry := worktree.FindEntry(manifest, slug, run)
+	switch {
+	case entry == nil && run != "":
+		return nil, fmt.Errorf("no worktree for %q in run %s", slug, run)
+	case entry == nil:
+		return nil, fmt.Errorf("no worktree for %q in the manifest (its worktree was removed; run with --keep-failed to keep the worktrees of failed tasks)", slug)
+	case entry.Task == nil:
+		return nil, fmt.Errorf("cannot retry %q: no task recorded in the manifest", slug)
+	case entry.Status == "running":
+		return nil, fmt.Errorf("cannot retry %q: it is still running (use 'mochi resume' if its run crashed)", slug)
+	}
+	if _, err := os.Stat(entry.Path); err != nil {
+		return nil, fmt.Errorf("cannot retry %q: worktree %s is missing — run 'mochi prune'", slug, entry.Path)
+	}
+	return entry, nil
+}
+
+// retryPrerequisites returns the branches of entry's prerequisites to merge
+// before a retry. Prerequisites that are gone from the manifest succeeded and
+// were merged by the first run, unless entry was skipped before that merge.
+func retryPrerequisites(manifest []*worktree.Entry, entry *worktree.Entry) ([]string, error) {
+	var branches []string
+	for _, dep := range entry.Task.DependsOn {
+		d := worktree.FindEntry(manifest, dep, entry.RunID)
+		switch {
+		case d == nil && entry.Status == "skipped":
+			return nil, fmt.Errorf("cannot retry %q: the worktree of its prerequisite %q was removed", entry.Slug, dep)
+		case d == nil:
+			continue
+		case d.Status != "done":
+			return nil, fmt.Errorf("cannot retry %q: prerequisite %q is %s — retry it first", entry.Slug, dep, d.Status)
+		}
+		branches = append(branches, d.Branch)
+	}
+	return branches, nil
+}
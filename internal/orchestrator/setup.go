@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/setup"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+// newPreparer maps the setup config onto a setup.Preparer for the run.
+func newPreparer(cfg config.Config, repoRoot string) *setup.Preparer {
+	p := &setup.Preparer{
+		RepoRoot: repoRoot,
+		Copy:     cfg.Setup.Copy,
+		Link:     cfg.Setup.Link,
+		Timeout:  time.Duration(cfg.Timeout) * time.Second,
+	}
+	for _, c := range cfg.Setup.Commands {
+		p.Steps = append(p.Steps, setup.Step{Run: c.Run, Outputs: c.Outputs, Key: c.Key})
+	}
+	return p
+}
+
+// prepareWorktree runs the worktree setup for entry before its agent starts,
+// logging to <LogDir>/<slug>-setup.log, and records in the manifest that the
+// worktree is ready so resumed runs skip it. A failure is returned as the
+// task's result.
+func prepareWorktree(ctx context.Context, cfg config.Config, prep *setup.Preparer, wm *worktree.Manager, entry *worktree.Entry) agent.Result {
+	started := time.Now()
+	logPath := filepath.Join(cfg.LogDir, entry.Slug+"-setup.log")
+	result := agent.Result{Slug: entry.Slug, LogPath: logPath}
+
+	f, err := os.Create(logPath)
+	if err != nil {
+		result.Error = fmt.Errorf("setup: cannot create log: %w", err)
+		return result
+	}
+	err = prep.Prepare(ctx, entry.Path, f)
+	f.Close()
+	result.Duration = time.Since(started)
+
+	switch {
+	case ctx.Err() != nil:
+		result.Error = fmt.Errorf("setup cancelled: %w", ctx.Err())
+	case err != nil:
+		result.Error = fmt.Errorf("worktree setup failed: %w", err)
+	default:
+		entry.Prepared = true
+		if err := wm.Update(entry.Slug, func(e *worktree.Entry) { e.Prepared = true }); err != nil {
+			result.Error = err
+		} else {
+			result.Success = true
+		}
+	}
+	return result
+}
+
+// setupSummary describes the worktree setup in one line for --dry-run.
+func setupSummary(s config.SetupConfig) string {
+	var parts []string
+	if len(s.Copy) > 0 {
+		parts = append(parts, "copy "+strings.Join(s.Copy, ", "))
+	}
+	if len(s.Link) > 0 {
+		parts = append(parts, "link "+strings.Join(s.Link, ", "))
+	}
+	for _, c := range s.Commands {
+		if len(c.Outputs) > 0 {
+			parts = append(parts, fmt.Sprintf("run %q (caches %s)", c.Run, strings.Join(c.Outputs, ", ")))
+		} else {
+			parts = append(parts, fmt.Sprintf("run %q", c.Run))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,382 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Audit report files written to OutputDir for the whole run.
+const (
+	auditReportFile = "audit-report.md"
+	auditSARIFFile  = "audit-report.sarif"
+)
+
+// severities lists the finding severities from most to least severe.
+var severities = []string{"critical", "high", "medium", "low", "info"}
+
+const auditInstructions = `- This is a read-only audit: do not modify, create or commit any files.
+- Report every finding at the end of your response in a single fenced ` + "```json" + ` block
+  holding an array of objects of this shape:
+  {"severity": "critical|high|medium|low|info", "file": "<path relative to the repo root>", "line": <line number or 0>, "description": "<what is wrong and why it matters>", "recommendation": "<how to fix it>"}
+- If you find nothing, output an empty array.`
+
+// Finding is one issue reported by an audit task.
+type Finding struct {
+	Task           string `json:"task,omitempty"`
+	Severity       string `json:"severity"`
+	File           string `json:"file,omitempty"`
+	Line           int    `json:"line,omitempty"`
+	Description    string `json:"description"`
+	Recommendation string `json:"recommendation,omitempty"`
+}
+
+// Location formats the finding's file and line, e.g. "auth/login.go:42".
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// ParseFindings extracts the findings array from a worker's output. The array
+// may stand alone or sit in a ```json fence; when there are several fences the
+// last one wins. Severities are normalized and findings sorted by severity.
+func ParseFindings(output string) ([]Finding, error) {
+	candidate := output
+	if start := strings.LastIndex(candidate, "```json"); start >= 0 {
+		candidate = candidate[start+len("```json"):]
+		if end := strings.Index(candidate, "```"); end >= 0 {
+			candidate = candidate[:end]
+		}
+	}
+	start := strings.Index(candidate, "[")
+	end := strings.LastIndex(candidate, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("output: no findings array in worker output")
+	}
+	var findings []Finding
+	if err := json.Unmarshal([]byte(candidate[start:end+1]), &findings); err != nil {
+		return nil, fmt.Errorf("output: cannot parse findings: %w", err)
+	}
+	for i := range findings {
+		findings[i].Severity = normalizeSeverity(findings[i].Severity)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+func normalizeSeverity(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	case "note":
+		return "low"
+	}
+	for _, known := range severities {
+		if s == known {
+			return s
+		}
+	}
+	return "info"
+}
+
+func severityRank(s string) int {
+	for i, known := range severities {
+		if s == known {
+			return i
+		}
+	}
+	return len(severities)
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank(findings[i].Severity) < severityRank(findings[j].Severity)
+	})
+}
+
+// handleAudit writes the task's findings as <slug>-audit.md and
+// <slug>-audit.sarif to OutputDir.
+func handleAudit(opts Options) (string, error) {
+	findings, err := taskFindings(opts)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return "", fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
+	}
+
+	content, err := renderTemplate(string(ModeAudit), opts, buildAuditTaskContent(opts, findings), findings)
+	if err != nil {
+		return "", err
+	}
+	mdPath := filepath.Join(opts.OutputDir, opts.Task.Slug+"-audit.md")
+	if err := os.WriteFile(mdPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("output: cannot write audit %q: %w", mdPath, err)
+	}
+	return mdPath, writeSARIF(filepath.Join(opts.OutputDir, opts.Task.Slug+"-audit.sarif"), findings)
+}
+
+// finalizeAudit aggregates the findings of every task into audit-report.md
+// and audit-report.sarif. Tasks whose findings cannot be parsed are listed in
+// the report instead of failing it.
+func finalizeAudit(outputDir string, tasks []Options) ([]string, error) {
+	var all []Finding
+	var unparsed []string
+	perTask := make(map[string]int)
+	for _, t := range tasks {
+		findings, err := taskFindings(t)
+		if err != nil {
+			unparsed = append(unparsed, t.Task.Slug)
+			continue
+		}
+		perTask[t.Task.Slug] = len(findings)
+		all = append(all, findings...)
+	}
+	sortFindings(all)
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("output: cannot create output dir %q: %w", outputDir, err)
+	}
+	mdPath := filepath.Join(outputDir, auditReportFile)
+	if err := os.WriteFile(mdPath, []byte(buildAuditRunContent(tasks, perTask, unparsed, all)), 0644); err != nil {
+		return nil, fmt.Errorf("output: cannot write audit report %q: %w", mdPath, err)
+	}
+	sarifPath := filepath.Join(outputDir, auditSARIFFile)
+	if err := writeSARIF(sarifPath, all); err != nil {
+		return nil, err
+	}
+	return []string{mdPath, sarifPath}, nil
+}
+
+func taskFindings(opts Options) ([]Finding, error) {
+	findings, err := ParseFindings(opts.WorkerResult.Output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range findings {
+		findings[i].Task = opts.Task.Slug
+	}
+	return findings, nil
+}
+
+func severityCounts(findings []Finding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+func writeSeverityTable(b *strings.Builder, findings []Finding) {
+	counts := severityCounts(findings)
+	b.WriteString("| Severity | Findings |\n|---|---|\n")
+	for _, s := range severities {
+		fmt.Fprintf(b, "| %s | %d |\n", s, counts[s])
+	}
+	b.WriteString("\n")
+}
+
+func writeFindings(b *strings.Builder, findings []Finding, withTask bool) {
+	for i, f := range findings {
+		fmt.Fprintf(b, "### %d. %s", i+1, strings.ToUpper(f.Severity))
+		if loc := f.Location(); loc != "" {
+			fmt.Fprintf(b, " — `%s`", loc)
+		}
+		b.WriteString("\n\n")
+		if withTask {
+			fmt.Fprintf(b, "**Task:** %s\n\n", f.Task)
+		}
+		b.WriteString(strings.TrimSpace(f.Description))
+		b.WriteString("\n\n")
+		if f.Recommendation != "" {
+			fmt.Fprintf(b, "**Recommendation:** %s\n\n", strings.TrimSpace(f.Recommendation))
+		}
+	}
+}
+
+func buildAuditTaskContent(opts Options, findings []Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Audit: %s\n\n", opts.Task.Slug)
+	fullTask := opts.Task.Title
+	if opts.Task.Description != "" {
+		fullTask += "\n\n" + opts.Task.Description
+	}
+	fmt.Fprintf(&b, "**Task:** %s\n\n", fullTask)
+	fmt.Fprintf(&b, "**Model:** %s\n\n", opts.Task.Model)
+	fmt.Fprintf(&b, "**Iterations:** %d\n\n", opts.Iterations)
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	b.WriteString("---\n\n")
+	writeSeverityTable(&b, findings)
+	b.WriteString("## Findings\n\n")
+	if len(findings) == 0 {
+		b.WriteString("No findings.\n")
+		return b.String()
+	}
+	writeFindings(&b, findings, false)
+	return b.String()
+}
+
+func buildAuditRunContent(tasks []Options, perTask map[string]int, unparsed []string, all []Finding) string {
+	var b strings.Builder
+	b.WriteString("# Audit Report\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "**Tasks:** %d  \n**Findings:** %d\n\n", len(tasks), len(all))
+	b.WriteString("---\n\n")
+	writeSeverityTable(&b, all)
+
+	b.WriteString("## Tasks\n\n| Task | Findings | Report |\n|---|---|---|\n")
+	for _, t := range tasks {
+		slug := t.Task.Slug
+		if n, ok := perTask[slug]; ok {
+			fmt.Fprintf(&b, "| %s | %d | [%s-audit.md](%s-audit.md) |\n", slug, n, slug, slug)
+		} else {
+			fmt.Fprintf(&b, "| %s | — | findings could not be parsed |\n", slug)
+		}
+	}
+	b.WriteString("\n")
+	if len(unparsed) > 0 {
+		fmt.Fprintf(&b, "> Not included: %s — the worker output had no valid findings block.\n\n", strings.Join(unparsed, ", "))
+	}
+
+	b.WriteString("## Findings\n\n")
+	if len(all) == 0 {
+		b.WriteString("No findings.\n")
+		return b.String()
+	}
+	writeFindings(&b, all, true)
+	return b.String()
+}
+
+// SARIF 2.1.0, limited to what code scanning tools need to display findings.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	}
+	return "note"
+}
+
+// buildSARIF converts findings to a SARIF log with one rule per task.
+func buildSARIF(findings []Finding) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "MOCHI",
+			InformationURI: "https://github.com/thisguymartin/Mochi",
+		}},
+		Results: []sarifResult{},
+	}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		ruleID := "mochi/" + f.Task
+		if !seen[ruleID] {
+			seen[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: "Audit task " + f.Task},
+			})
+		}
+		text := strings.TrimSpace(f.Description)
+		if f.Recommendation != "" {
+			text += "\n\nRecommendation: " + strings.TrimSpace(f.Recommendation)
+		}
+		result := sarifResult{
+			RuleID:     ruleID,
+			Level:      sarifLevel(f.Severity),
+			Message:    sarifMessage{Text: text},
+			Properties: map[string]string{"severity": f.Severity},
+		}
+		if f.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(f.File)},
+			}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+func writeSARIF(path string, findings []Finding) error {
+	data, err := json.MarshalIndent(buildSARIF(findings), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("output: cannot write SARIF %q: %w", path, err)
+	}
+	return nil
+}
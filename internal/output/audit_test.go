@@ -0,0 +1,105 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+)
+
+const auditOutput = "I reviewed the auth package.\n\n```json\n" + `[
+  {"severity": "low", "file": "auth/session.go", "line": 0, "description": "Session cookie lacks SameSite", "recommendation": "Set SameSite=Lax"},
+  {"severity": "CRITICAL", "file": "auth/login.go", "line": 42, "description": "SQL built with string concatenation", "recommendation": "Use a parameterized query"},
+  {"severity": "bogus", "description": "No rate limiting on login"}
+]` + "\n```\n"
+
+func auditTask(slug, out string) Options {
+	return Options{
+		Mode:         ModeAudit,
+		Task:         parser.Task{Slug: slug, Title: "Audit " + slug, Model: "fake-1"},
+		WorkerResult: agent.Result{Slug: slug, Success: true, Output: out},
+		Iterations:   1,
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	findings, err := ParseFindings(auditOutput)
+	if err != nil {
+		t.Fatalf("ParseFindings failed: %v", err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3", len(findings))
+	}
+	if findings[0].Severity != "critical" || findings[0].Location() != "auth/login.go:42" {
+		t.Errorf("first finding = %+v; want the critical one first", findings[0])
+	}
+	if findings[2].Severity != "info" {
+		t.Errorf("unknown severity = %q; want info", findings[2].Severity)
+	}
+
+	if f, err := ParseFindings("Nothing found.\n```json\n[]\n```"); err != nil || len(f) != 0 {
+		t.Errorf("empty array = %v, %v", f, err)
+	}
+	if _, err := ParseFindings("I could not finish the audit."); err == nil {
+		t.Error("expected an error when there is no findings array")
+	}
+}
+
+func TestAudit_TaskAndRunReports(t *testing.T) {
+	dir := t.TempDir()
+	tasks := []Options{auditTask("audit-auth", auditOutput), auditTask("audit-api", "```json\n[]\n```"), auditTask("audit-ui", "no block")}
+	for i := range tasks {
+		tasks[i].OutputDir = dir
+	}
+
+	if _, err := Handle(tasks[0]); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "audit-auth-audit.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| critical | 1 |", "### 1. CRITICAL — `auth/login.go:42`", "**Recommendation:** Use a parameterized query"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("task report missing %q:\n%s", want, md)
+		}
+	}
+	if _, err := Handle(tasks[2]); err == nil {
+		t.Error("Handle should fail for output without findings")
+	}
+
+	paths, err := Finalize(ModeAudit, dir, tasks)
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Finalize wrote %v", paths)
+	}
+	report, _ := os.ReadFile(filepath.Join(dir, "audit-report.md"))
+	for _, want := range []string{"**Findings:** 3", "| audit-api | 0 |", "| audit-ui | — |", "**Task:** audit-auth"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("run report missing %q:\n%s", want, report)
+		}
+	}
+
+	var log sarifLog
+	data, _ := os.ReadFile(filepath.Join(dir, "audit-report.sarif"))
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	results := log.Runs[0].Results
+	if log.Version != "2.1.0" || len(results) != 3 {
+		t.Fatalf("SARIF = %+v", log)
+	}
+	first := results[0]
+	if first.Level != "error" || first.RuleID != "mochi/audit-auth" || first.Locations[0].PhysicalLocation.Region.StartLine != 42 {
+		t.Errorf("first SARIF result = %+v", first)
+	}
+	if len(results[2].Locations) != 0 {
+		t.Errorf("finding without a file should have no location: %+v", results[2])
+	}
+}
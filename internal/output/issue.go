@@ -0,0 +1,117 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	gh "github.com/thisguymartin/ai-forge/internal/github"
+	"github.com/thisguymartin/ai-forge/internal/reviewer"
+)
+
+// maxIssueBody is GitHub's limit on the length of an issue body, in
+// characters.
+const maxIssueBody = 65536
+
+const truncatedNote = "\n\n_… output truncated_"
+
+const issueInstructions = `- This is a read-only task: do not modify, create or commit any files.
+- Your final response is filed as a GitHub issue. Write it in Markdown: what you
+  found, the files involved by their path relative to the repo root, and the
+  recommended next steps.`
+
+// handleIssue files the task's findings as a GitHub issue titled after the
+// task. When an open issue was already filed for the task, it is left as is
+// so re-runs do not open duplicates.
+func handleIssue(opts Options) (string, error) {
+	existing, found, err := gh.FindOpenIssue(opts.RepoRoot, opts.Task.Slug, opts.Task.Title)
+	if err != nil {
+		return "", fmt.Errorf("output: cannot list open issues: %w", err)
+	}
+	if found {
+		return existing.URL + " (already open)", nil
+	}
+	body, err := issueBody(opts)
+	if err != nil {
+		return "", err
+	}
+	url, err := gh.CreateIssue(gh.IssueOptions{
+		Slug:      opts.Task.Slug,
+		Title:     opts.Task.Title,
+		Body:      body,
+		Labels:    opts.IssueLabels,
+		Assignees: opts.IssueAssignees,
+		RepoRoot:  opts.RepoRoot,
+	})
+	if err != nil {
+		return "", fmt.Errorf("output: %w", err)
+	}
+	return url, nil
+}
+
+// issueBody renders the issue body for the task, cut so that, once
+// CreateIssue appends the task's marker, it still fits GitHub's limit.
+func issueBody(opts Options) (string, error) {
+	body, err := renderTemplate(string(ModeIssue), opts, buildIssueBody(opts), nil)
+	if err != nil {
+		return "", err
+	}
+	return truncateRunes(body, issueBodyLimit(opts.Task.Slug)), nil
+}
+
+// issueBodyLimit is the room left for an issue body by GitHub's limit and
+// the marker CreateIssue appends.
+func issueBodyLimit(slug string) int {
+	return maxIssueBody - utf8.RuneCountInString(gh.IssueBody("", slug))
+}
+
+func buildIssueBody(opts Options) string {
+	var head strings.Builder
+	if opts.Task.Description != "" {
+		head.WriteString(opts.Task.Description)
+		head.WriteString("\n\n")
+	}
+	fmt.Fprintf(&head, "**Model:** %s  \n**Iterations:** %d\n\n", opts.Task.Model, opts.Iterations)
+	head.WriteString("## Findings\n\n")
+
+	var tail strings.Builder
+	tail.WriteString("\n\n")
+	if review := reviewer.StoredMarkdown(opts.MemCtx.Review); review != "" {
+		tail.WriteString("## Review\n\n")
+		tail.WriteString(review)
+		tail.WriteString("\n\n")
+	} else if opts.MemCtx.Progress != "" {
+		tail.WriteString("## Progress\n\n")
+		tail.WriteString(strings.TrimSpace(opts.MemCtx.Progress))
+		tail.WriteString("\n\n")
+	}
+	tail.WriteString("---\n")
+	tail.WriteString("🤖 Filed by [MOCHI](https://github.com/thisguymartin/ai-forge)\n")
+
+	// The findings get whatever the rest of the body leaves under the limit.
+	findings := strings.TrimSpace(opts.WorkerResult.Output)
+	limit := issueBodyLimit(opts.Task.Slug)
+	budget := limit - utf8.RuneCountInString(head.String()) - utf8.RuneCountInString(tail.String())
+	if utf8.RuneCountInString(findings) > budget {
+		findings = truncateRunes(findings, budget-utf8.RuneCountInString(truncatedNote)) + truncatedNote
+	}
+	return truncateRunes(head.String()+findings+tail.String(), limit)
+}
+
+// truncateRunes cuts s to at most n characters, never splitting a rune.
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
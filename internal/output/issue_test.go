@@ -0,0 +1,66 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	gh "github.com/thisguymartin/ai-forge/internal/github"
+	"github.com/thisguymartin/ai-forge/internal/memory"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+)
+
+func TestBuildIssueBody(t *testing.T) {
+	opts := Options{
+		Mode:         ModeIssue,
+		Task:         parser.Task{Slug: "audit-auth", Title: "Audit auth", Description: "Focus on session handling.", Model: "fake-1"},
+		WorkerResult: agent.Result{Slug: "audit-auth", Success: true, Output: "Sessions never expire (`auth/session.go`)."},
+		MemCtx:       memory.Context{Review: `{"done": true, "summary": "Findings are accurate"}`},
+		Iterations:   2,
+	}
+	body := buildIssueBody(opts)
+	for _, want := range []string{"Focus on session handling.", "**Iterations:** 2", "## Findings\n\nSessions never expire", "## Review", "Findings are accurate"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("issue body missing %q:\n%s", want, body)
+		}
+	}
+
+	// Long sections are cut on a rune boundary so the body gh files, marker
+	// included, fits.
+	tests := []struct {
+		name        string
+		description string
+		output      string
+		template    string
+	}{
+		{"long output", "Focus on session handling.", strings.Repeat("x", maxIssueBody), ""},
+		{"multibyte output", "Focus on session handling.", strings.Repeat("é", maxIssueBody), ""},
+		{"long description", strings.Repeat("d", maxIssueBody), "Sessions never expire.", ""},
+		{"long template", "Focus on session handling.", strings.Repeat("é", maxIssueBody), "{{.Default}}\n\n{{.Default}}"},
+	}
+	for _, tt := range tests {
+		opts.RepoRoot = t.TempDir()
+		if tt.template != "" {
+			writeTemplate(t, opts.RepoRoot, string(ModeIssue), tt.template)
+		}
+		opts.Task.Description = tt.description
+		opts.WorkerResult.Output = tt.output
+		body, err := issueBody(opts)
+		if err != nil {
+			t.Fatalf("%s: issueBody failed: %v", tt.name, err)
+		}
+		filed := gh.IssueBody(body, opts.Task.Slug)
+		if n := utf8.RuneCountInString(filed); n > maxIssueBody {
+			t.Errorf("%s: filed body is %d characters; want at most %d", tt.name, n, maxIssueBody)
+		}
+		if !utf8.ValidString(filed) || !strings.HasSuffix(filed, gh.IssueMarker(opts.Task.Slug)+"\n") {
+			t.Errorf("%s: filed body splits a rune or lost its marker", tt.name)
+		}
+	}
+	opts.Task.Description = ""
+	opts.WorkerResult.Output = strings.Repeat("é", maxIssueBody)
+	if body := buildIssueBody(opts); !strings.Contains(body, "output truncated") || !strings.Contains(body, "## Review") {
+		t.Error("long output did not leave room for the truncation note and the review")
+	}
+}
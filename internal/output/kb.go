@@ -0,0 +1,327 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kbIndexFile is the knowledge base's index page in OutputDir.
+const kbIndexFile = "index.md"
+
+// kbRelatedMarker separates a page's body from its generated "Related pages"
+// section, which Finalize rewrites on every run.
+const kbRelatedMarker = "<!-- mochi:related -->"
+
+const kbInstructions = `- This is a documentation task: do not modify, create or commit any files.
+- Write the documentation page as your final response, in Markdown, starting with
+  a level-1 heading. Refer to source files by their path relative to the repo root
+  and to functions, types and other symbols by name, each in backticks.
+- End your response with a fenced ` + "```json" + ` block listing what the page covers:
+  {"files": ["<path>", ...], "symbols": ["<name>", ...]}`
+
+// kbMeta is the YAML front matter of a knowledge base page.
+type kbMeta struct {
+	Task    string   `yaml:"task"`
+	Source  string   `yaml:"source"` // task title the page was written for
+	Title   string   `yaml:"title"`
+	Model   string   `yaml:"model,omitempty"`
+	Updated string   `yaml:"updated"`
+	Files   []string `yaml:"files,omitempty"`
+	Symbols []string `yaml:"symbols,omitempty"`
+}
+
+// kbPage is a knowledge base page on disk.
+type kbPage struct {
+	Meta kbMeta
+	Body string // Markdown between the front matter and the related section
+	File string // file name within OutputDir
+}
+
+var (
+	kbRefsBlock = regexp.MustCompile("(?s)```json\\s*(\\{.*?\\})\\s*```\\s*$")
+	kbCodeSpan  = regexp.MustCompile("`([^`\\s]+)`")
+	kbHeading   = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+)
+
+// handleKnowledgeBase writes the task's page to OutputDir. A page written for
+// the same task in an earlier run is replaced rather than duplicated.
+func handleKnowledgeBase(opts Options) (string, error) {
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return "", fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
+	}
+	body, files, symbols := parseKBOutput(opts.WorkerResult.Output)
+	if strings.TrimSpace(body) == "" {
+		return "", fmt.Errorf("output: worker returned an empty page")
+	}
+	body, err := renderTemplate(string(ModeKnowledgeBase), opts, body, nil)
+	if err != nil {
+		return "", err
+	}
+
+	title := opts.Task.Title
+	if m := kbHeading.FindStringSubmatch(body); m != nil {
+		title = strings.TrimSpace(m[1])
+	}
+	page := kbPage{
+		Meta: kbMeta{
+			Task:    opts.Task.Slug,
+			Source:  opts.Task.Title,
+			Title:   title,
+			Model:   opts.Task.Model,
+			Updated: time.Now().Format("2006-01-02 15:04:05"),
+			Files:   files,
+			Symbols: symbols,
+		},
+		Body: body,
+		File: opts.Task.Slug + ".md",
+	}
+	// A task whose slug changed between runs still updates its old page.
+	existing, err := loadKBPages(opts.OutputDir)
+	if err != nil {
+		return "", fmt.Errorf("output: cannot list pages in %q: %w", opts.OutputDir, err)
+	}
+	for _, old := range existing {
+		if old.Meta.Source == page.Meta.Source && old.File != page.File {
+			page.File = old.File
+			break
+		}
+	}
+	return filepath.Join(opts.OutputDir, page.File), writeKBPage(opts.OutputDir, page, "")
+}
+
+// parseKBOutput splits the worker output into the page body and the files and
+// symbols it covers. Without a references block, file paths are taken from
+// the body's code spans.
+func parseKBOutput(output string) (body string, files, symbols []string) {
+	body = strings.TrimSpace(output)
+	if m := kbRefsBlock.FindStringSubmatchIndex(body); m != nil {
+		var refs struct {
+			Files   []string `json:"files"`
+			Symbols []string `json:"symbols"`
+		}
+		if err := json.Unmarshal([]byte(body[m[2]:m[3]]), &refs); err == nil {
+			body = strings.TrimSpace(body[:m[0]])
+			return body, uniqueSorted(refs.Files), uniqueSorted(refs.Symbols)
+		}
+	}
+	for _, m := range kbCodeSpan.FindAllStringSubmatch(body, -1) {
+		if looksLikePath(m[1]) {
+			files = append(files, m[1])
+		}
+	}
+	return body, uniqueSorted(files), nil
+}
+
+// looksLikePath reports whether a code span names a file: it has a directory
+// separator or a short extension, and no call parentheses.
+func looksLikePath(s string) bool {
+	if strings.ContainsAny(s, "()") || strings.HasPrefix(s, "-") {
+		return false
+	}
+	if strings.Contains(s, "/") {
+		return true
+	}
+	ext := filepath.Ext(s)
+	return len(ext) > 1 && len(ext) <= 5 && ext != s
+}
+
+func uniqueSorted(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range items {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "./")
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		out = append(out, item)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func writeKBPage(dir string, page kbPage, related string) error {
+	meta, err := yaml.Marshal(page.Meta)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.Write(meta)
+	b.WriteString("---\n\n")
+	b.WriteString(strings.TrimSpace(page.Body))
+	b.WriteString("\n\n" + kbRelatedMarker + "\n")
+	if related != "" {
+		b.WriteString("\n" + related)
+	}
+	path := filepath.Join(dir, page.File)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("output: cannot write page %q: %w", path, err)
+	}
+	return nil
+}
+
+func readKBPage(path string) (kbPage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return kbPage{}, err
+	}
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		return kbPage{}, fmt.Errorf("output: %s has no front matter", path)
+	}
+	front, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		return kbPage{}, fmt.Errorf("output: %s has no front matter", path)
+	}
+	var page kbPage
+	if err := yaml.Unmarshal(front, &page.Meta); err != nil {
+		return kbPage{}, fmt.Errorf("output: cannot parse front matter of %s: %w", path, err)
+	}
+	if page.Meta.Task == "" {
+		return kbPage{}, fmt.Errorf("output: %s is not a knowledge base page", path)
+	}
+	text, _, _ := strings.Cut(string(body), kbRelatedMarker)
+	page.Body = strings.TrimSpace(text)
+	page.File = filepath.Base(path)
+	return page, nil
+}
+
+// loadKBPages reads every knowledge base page in dir, including those from
+// earlier runs. Other Markdown files are ignored.
+func loadKBPages(dir string) ([]kbPage, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	var pages []kbPage
+	for _, name := range names {
+		if filepath.Base(name) == kbIndexFile {
+			continue
+		}
+		if page, err := readKBPage(name); err == nil {
+			pages = append(pages, page)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return strings.ToLower(pages[i].Meta.Title) < strings.ToLower(pages[j].Meta.Title)
+	})
+	return pages, nil
+}
+
+// finalizeKnowledgeBase cross-links every page in outputDir, old and new,
+// with the pages that cover the same files or symbols, and rebuilds the index.
+func finalizeKnowledgeBase(outputDir string) ([]string, error) {
+	pages, err := loadKBPages(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("output: cannot list pages in %q: %w", outputDir, err)
+	}
+
+	for i, page := range pages {
+		var related strings.Builder
+		for j, other := range pages {
+			if i == j {
+				continue
+			}
+			shared := sharedRefs(page.Meta, other.Meta)
+			if len(shared) == 0 {
+				continue
+			}
+			fmt.Fprintf(&related, "- [%s](%s) — shares `%s`\n", other.Meta.Title, other.File, strings.Join(shared, "`, `"))
+		}
+		section := ""
+		if related.Len() > 0 {
+			section = "## Related pages\n\n" + related.String()
+		}
+		if err := writeKBPage(outputDir, page, section); err != nil {
+			return nil, err
+		}
+	}
+
+	indexPath := filepath.Join(outputDir, kbIndexFile)
+	if err := os.WriteFile(indexPath, []byte(buildKBIndex(pages)), 0644); err != nil {
+		return nil, fmt.Errorf("output: cannot write index %q: %w", indexPath, err)
+	}
+	return []string{indexPath}, nil
+}
+
+// sharedRefs returns the files and symbols covered by both pages.
+func sharedRefs(a, b kbMeta) []string {
+	in := make(map[string]bool)
+	for _, r := range append(append([]string(nil), b.Files...), b.Symbols...) {
+		in[r] = true
+	}
+	var shared []string
+	for _, r := range append(append([]string(nil), a.Files...), a.Symbols...) {
+		if in[r] {
+			shared = append(shared, r)
+		}
+	}
+	return shared
+}
+
+func buildKBIndex(pages []kbPage) string {
+	var b strings.Builder
+	b.WriteString("# Knowledge Base\n\n")
+	fmt.Fprintf(&b, "_Generated by MOCHI on %s. Edits to this page are overwritten._\n\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	b.WriteString("## Pages\n\n")
+	for _, p := range pages {
+		fmt.Fprintf(&b, "- [%s](%s)", p.Meta.Title, p.File)
+		if summary := kbSummary(p.Body); summary != "" {
+			b.WriteString(" — " + summary)
+		}
+		b.WriteString("\n")
+	}
+
+	byFile := make(map[string][]kbPage)
+	for _, p := range pages {
+		for _, f := range p.Meta.Files {
+			byFile[f] = append(byFile[f], p)
+		}
+	}
+	if len(byFile) > 0 {
+		files := make([]string, 0, len(byFile))
+		for f := range byFile {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+		b.WriteString("\n## Files\n\n| File | Pages |\n|---|---|\n")
+		for _, f := range files {
+			links := make([]string, len(byFile[f]))
+			for i, p := range byFile[f] {
+				links[i] = fmt.Sprintf("[%s](%s)", p.Meta.Title, p.File)
+			}
+			fmt.Fprintf(&b, "| `%s` | %s |\n", f, strings.Join(links, ", "))
+		}
+	}
+	return b.String()
+}
+
+// kbSummary returns the first paragraph line of a page body, shortened.
+func kbSummary(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "```") {
+			continue
+		}
+		if len(line) > 120 {
+			cut := strings.LastIndex(line[:120], " ")
+			if cut <= 0 {
+				cut = 120
+			}
+			line = line[:cut] + "…"
+		}
+		return line
+	}
+	return ""
+}
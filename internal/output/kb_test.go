@@ -0,0 +1,100 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+)
+
+func kbTask(dir, slug, title, out string) Options {
+	return Options{
+		Mode:         ModeKnowledgeBase,
+		Task:         parser.Task{Slug: slug, Title: title, Model: "fake-1"},
+		WorkerResult: agent.Result{Slug: slug, Success: true, Output: out},
+		OutputDir:    dir,
+	}
+}
+
+func readPage(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseKBOutput(t *testing.T) {
+	body, files, symbols := parseKBOutput("# Auth\n\nSee `auth/login.go`.\n\n```json\n{\"files\": [\"./auth/login.go\", \"auth/session.go\"], \"symbols\": [\"Login\"]}\n```\n")
+	if strings.Contains(body, "```json") || !strings.HasPrefix(body, "# Auth") {
+		t.Errorf("body = %q", body)
+	}
+	if strings.Join(files, ",") != "auth/login.go,auth/session.go" || strings.Join(symbols, ",") != "Login" {
+		t.Errorf("files = %v, symbols = %v", files, symbols)
+	}
+
+	_, files, _ = parseKBOutput("Uses `internal/db/conn.go`, `config.yaml`, `Open()` and `--verbose`.")
+	if strings.Join(files, ",") != "config.yaml,internal/db/conn.go" {
+		t.Errorf("fallback files = %v", files)
+	}
+}
+
+func TestKnowledgeBase_CrossLinksAndIndex(t *testing.T) {
+	dir := t.TempDir()
+	tasks := []Options{
+		kbTask(dir, "document-auth", "Document auth", "# Authentication\n\nHow login works.\n\n```json\n{\"files\": [\"auth/login.go\"], \"symbols\": [\"Session\"]}\n```"),
+		kbTask(dir, "document-api", "Document the API", "# HTTP API\n\nRoutes and handlers.\n\n```json\n{\"files\": [\"api/routes.go\"], \"symbols\": [\"Session\"]}\n```"),
+		kbTask(dir, "document-cli", "Document the CLI", "# CLI\n\nFlags.\n\n```json\n{\"files\": [\"cmd/root.go\"]}\n```"),
+	}
+	for _, task := range tasks {
+		if _, err := Handle(task); err != nil {
+			t.Fatalf("Handle(%s) failed: %v", task.Task.Slug, err)
+		}
+	}
+	if _, err := Finalize(ModeKnowledgeBase, dir, tasks); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	auth := readPage(t, dir, "document-auth.md")
+	if !strings.Contains(auth, "- [HTTP API](document-api.md) — shares `Session`") {
+		t.Errorf("auth page missing link to the API page:\n%s", auth)
+	}
+	if strings.Contains(auth, "document-cli.md") {
+		t.Errorf("auth page should not link the unrelated CLI page:\n%s", auth)
+	}
+	index := readPage(t, dir, "index.md")
+	for _, want := range []string{"- [Authentication](document-auth.md) — How login works.", "| `cmd/root.go` | [CLI](document-cli.md) |"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index missing %q:\n%s", want, index)
+		}
+	}
+}
+
+func TestKnowledgeBase_RerunUpdatesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	first := kbTask(dir, "document-auth", "Document auth", "# Auth v1\n\nOld text.")
+	if _, err := Handle(first); err != nil {
+		t.Fatal(err)
+	}
+	// Same task, new slug and new content on a later run.
+	second := kbTask(dir, "auth-docs", "Document auth", "# Auth v2\n\nNew text.")
+	if _, err := Handle(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Finalize(ModeKnowledgeBase, dir, []Options{second}); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(pages) != 2 { // the page and index.md
+		t.Fatalf("got files %v; want one page plus the index", pages)
+	}
+	page := readPage(t, dir, "document-auth.md")
+	if !strings.Contains(page, "New text.") || strings.Contains(page, "Old text.") {
+		t.Errorf("page was not updated:\n%s", page)
+	}
+}
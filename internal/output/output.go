@@ -44,64 +44,99 @@ type Options struct {
 	Iterations   int
 	OutputDir    string
 	RepoRoot     string
+
+	// Issue mode: labels and assignees of the filed issues.
+	IssueLabels    []string
+	IssueAssignees []string
 }
 
-// Handle dispatches the appropriate output handler based on Mode.
+// Handle dispatches the appropriate output handler based on Mode and returns
+// where the output went: the file written or the issue URL.
 // ModePR is intentionally not handled here — it's managed by the orchestrator's
 // existing PR creation path.
-func Handle(opts Options) error {
+func Handle(opts Options) (string, error) {
 	switch opts.Mode {
 	case ModePR:
 		// PR mode is handled by the orchestrator; nothing to do here.
-		return nil
+		return "", nil
 	case ModeFile:
 		return handleFile(opts)
 	case ModeResearchReport:
 		return handleResearchReport(opts)
 	case ModeAudit:
-		// Stub: future implementation
-		return nil
+		return handleAudit(opts)
 	case ModeKnowledgeBase:
-		// Stub: future implementation
-		return nil
+		return handleKnowledgeBase(opts)
 	case ModeIssue:
-		// Stub: future implementation
-		return nil
+		return handleIssue(opts)
 	default:
-		return fmt.Errorf("unknown output mode %q", opts.Mode)
+		return "", fmt.Errorf("unknown output mode %q", opts.Mode)
+	}
+}
+
+// Instructions returns the worker prompt instructions for modes whose result
+// is a report rather than committed code, or "" for the default instructions.
+func Instructions(m Mode) string {
+	switch m {
+	case ModeAudit:
+		return auditInstructions
+	case ModeKnowledgeBase:
+		return kbInstructions
+	case ModeIssue:
+		return issueInstructions
 	}
+	return ""
+}
+
+// Finalize writes the run-level output of modes that aggregate across tasks,
+// given the Options each successful task was handled with. It returns the
+// paths it wrote; modes without a run-level output write nothing.
+func Finalize(m Mode, outputDir string, tasks []Options) ([]string, error) {
+	switch m {
+	case ModeAudit:
+		return finalizeAudit(outputDir, tasks)
+	case ModeKnowledgeBase:
+		return finalizeKnowledgeBase(outputDir)
+	}
+	return nil, nil
 }
 
 // handleFile writes the worker output as a plain markdown file to OutputDir.
-func handleFile(opts Options) error {
+func handleFile(opts Options) (string, error) {
 	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
-		return fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
+		return "", fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
 	}
 
 	filename := fmt.Sprintf("%s.md", opts.Task.Slug)
 	path := filepath.Join(opts.OutputDir, filename)
 
-	content := buildFileContent(opts)
+	content, err := renderTemplate(string(ModeFile), opts, buildFileContent(opts), nil)
+	if err != nil {
+		return "", err
+	}
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		return fmt.Errorf("output: cannot write file %q: %w", path, err)
+		return "", fmt.Errorf("output: cannot write file %q: %w", path, err)
 	}
-	return nil
+	return path, nil
 }
 
 // handleResearchReport writes a structured research report to OutputDir.
-func handleResearchReport(opts Options) error {
+func handleResearchReport(opts Options) (string, error) {
 	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
-		return fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
+		return "", fmt.Errorf("output: cannot create output dir %q: %w", opts.OutputDir, err)
 	}
 
 	filename := fmt.Sprintf("%s-report.md", opts.Task.Slug)
 	path := filepath.Join(opts.OutputDir, filename)
 
-	content := buildResearchReportContent(opts)
+	content, err := renderTemplate(string(ModeResearchReport), opts, buildResearchReportContent(opts), nil)
+	if err != nil {
+		return "", err
+	}
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		return fmt.Errorf("output: cannot write report %q: %w", path, err)
+		return "", fmt.Errorf("output: cannot write report %q: %w", path, err)
 	}
-	return nil
+	return path, nil
 }
 
 func buildFileContent(opts Options) string {
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/memory"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/reviewer"
+)
+
+// TemplateDir holds user-defined output templates, relative to the repo root.
+// A template named <name>.md.tmpl replaces the built-in layout of the output
+// with that name: pr, file, research-report, audit, knowledge-base or issue.
+const TemplateDir = ".mochi/templates"
+
+// PRTemplate is the name of the template for pull request descriptions.
+const PRTemplate = "pr"
+
+// TemplateData is the data model passed to output templates.
+type TemplateData struct {
+	Mode       Mode
+	Task       parser.Task
+	Result     agent.Result   // final worker result; .Result.Output is its response
+	Memory     memory.Context // memory files after the last iteration
+	Iterations int
+	Branch     string
+	Base       string    // commit the task's work started from
+	Review     string    // latest structured reviewer verdict as Markdown, if any
+	Diff       DiffStats // what the task branch changed relative to Base
+	Findings   []Finding // parsed findings, in audit mode
+	Generated  time.Time
+	Default    string // the built-in rendering, for templates that only wrap it
+}
+
+// DiffStats summarizes the changes of a task branch.
+type DiffStats struct {
+	Commits    int
+	Insertions int
+	Deletions  int
+	Files      []FileStat
+}
+
+// FileStat is the line count of one changed file. Binary files have no counts.
+type FileStat struct {
+	Path       string
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+var templateFuncs = template.FuncMap{
+	"trim": strings.TrimSpace,
+	"join": func(sep string, items []string) string { return strings.Join(items, sep) },
+	"truncate": func(n int, s string) string {
+		if t := truncateRunes(s, n); t != s {
+			return t + "…"
+		}
+		return s
+	},
+}
+
+// Render renders the user template called name for the task in opts. When the
+// repo defines no such template, def is returned unchanged; otherwise def is
+// available to the template as .Default.
+func Render(name string, opts Options, def string) (string, error) {
+	return renderTemplate(name, opts, def, nil)
+}
+
+func renderTemplate(name string, opts Options, def string, findings []Finding) (string, error) {
+	path := filepath.Join(opts.RepoRoot, TemplateDir, name+".md.tmpl")
+	src, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return def, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("output: cannot read template %q: %w", path, err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return "", fmt.Errorf("output: invalid template %q: %w", path, err)
+	}
+
+	data := TemplateData{
+		Mode:       opts.Mode,
+		Task:       opts.Task,
+		Result:     opts.WorkerResult,
+		Memory:     opts.MemCtx,
+		Iterations: opts.Iterations,
+		Review:     reviewer.StoredMarkdown(opts.MemCtx.Review),
+		Findings:   findings,
+		Generated:  time.Now(),
+		Default:    def,
+	}
+	if opts.Entry != nil {
+		data.Branch, data.Base = opts.Entry.Branch, opts.Entry.Base
+		if data.Diff, err = diffStats(opts.Entry.Path, opts.Entry.Base); err != nil {
+			return "", fmt.Errorf("output: cannot collect diff stats for %s: %w", opts.Task.Slug, err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("output: cannot render template %q: %w", path, err)
+	}
+	return b.String(), nil
+}
+
+// diffStats counts the commits and changed lines of the worktree at path
+// against base.
+func diffStats(path, base string) (DiffStats, error) {
+	var stats DiffStats
+	if path == "" || base == "" {
+		return stats, nil
+	}
+	// Count from where the branch forked, ignoring later commits on base.
+	fork, err := gitOutput(path, "merge-base", base, "HEAD")
+	if err != nil {
+		return stats, err
+	}
+	count, err := gitOutput(path, "rev-list", "--count", fork+"..HEAD")
+	if err != nil {
+		return stats, err
+	}
+	stats.Commits, _ = strconv.Atoi(count)
+
+	numstat, err := gitOutput(path, "diff", "--numstat", fork)
+	if err != nil {
+		return stats, err
+	}
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		f := FileStat{Path: fields[2]}
+		if fields[0] == "-" {
+			f.Binary = true
+		} else {
+			f.Insertions, _ = strconv.Atoi(fields[0])
+			f.Deletions, _ = strconv.Atoi(fields[1])
+		}
+		stats.Insertions += f.Insertions
+		stats.Deletions += f.Deletions
+		stats.Files = append(stats.Files, f)
+	}
+	return stats, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
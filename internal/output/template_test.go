@@ -0,0 +1,101 @@
+package output
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/parser"
+	"github.com/thisguymartin/ai-forge/internal/worktree"
+)
+
+func writeTemplate(t *testing.T, repoRoot, name, src string) {
+	t.Helper()
+	dir := filepath.Join(repoRoot, TemplateDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".md.tmpl"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender_DefaultWithoutTemplate(t *testing.T) {
+	got, err := Render(PRTemplate, Options{RepoRoot: t.TempDir()}, "built-in body")
+	if err != nil || got != "built-in body" {
+		t.Errorf("Render = %q, %v; want the default", got, err)
+	}
+}
+
+func TestRender_Truncate(t *testing.T) {
+	repo := t.TempDir()
+	writeTemplate(t, repo, PRTemplate, "{{truncate 3 .Task.Title}}|{{truncate 9 .Task.Title}}")
+	got, err := Render(PRTemplate, Options{RepoRoot: repo, Task: parser.Task{Title: "héllo"}}, "")
+	if err != nil || got != "hél…|héllo" {
+		t.Errorf("Render = %q, %v; want %q", got, err, "hél…|héllo")
+	}
+}
+
+func TestHandle_FileTemplate(t *testing.T) {
+	repo := t.TempDir()
+	writeTemplate(t, repo, "file", "## {{.Task.Title}} ({{.Iterations}} iterations)\n\n{{trim .Result.Output}}\n")
+	opts := Options{
+		Mode:         ModeFile,
+		Task:         parser.Task{Slug: "add-auth", Title: "Add auth"},
+		WorkerResult: agent.Result{Output: "  Done.  "},
+		Iterations:   3,
+		OutputDir:    filepath.Join(repo, "out"),
+		RepoRoot:     repo,
+	}
+	path, err := Handle(opts)
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "## Add auth (3 iterations)\n\nDone.\n" {
+		t.Errorf("file = %q", data)
+	}
+
+	writeTemplate(t, repo, "file", "{{.NoSuchField}}")
+	if _, err := Handle(opts); err == nil {
+		t.Error("Handle should fail on a template that does not execute")
+	}
+}
+
+func TestRender_DiffStats(t *testing.T) {
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n"), 0644)
+	run("add", ".")
+	run("commit", "-qm", "base")
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "b.go"), []byte("package a\n"), 0644)
+	run("add", ".")
+	run("commit", "-qm", "work")
+
+	writeTemplate(t, repo, PRTemplate, "{{.Diff.Commits}} commit, +{{.Diff.Insertions}} -{{.Diff.Deletions}}{{range .Diff.Files}} {{.Path}}{{end}}\n{{.Default}}")
+	got, err := Render(PRTemplate, Options{
+		Mode:     ModePR,
+		Entry:    &worktree.Entry{Path: repo, Branch: "main", Base: "HEAD~1"},
+		RepoRoot: repo,
+	}, "built-in body")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if want := "1 commit, +3 -0 a.go b.go\nbuilt-in body"; !strings.HasPrefix(got, want) {
+		t.Errorf("Render = %q; want %q", got, want)
+	}
+}
@@ -13,15 +13,28 @@ import (
 
 // Task represents a single unit of work parsed from a task file.
 type Task struct {
-	Title       string // Short, single-line title from the bullet point
-	Description string // Full, multi-line description of the task
-	Slug        string // Branch-safe identifier, e.g. "add-user-auth"
-	Model       string // Optional per-task model override
+	Title       string   `json:"title"`                 // Short, single-line title from the bullet point
+	Description string   `json:"description,omitempty"` // Full, multi-line description of the task
+	Slug        string   `json:"slug"`                  // Branch-safe identifier, e.g. "add-user-auth"
+	Model       string   `json:"model,omitempty"`       // Optional per-task model override
+	DependsOn   []string `json:"depends_on,omitempty"`  // Slugs of tasks that must finish first, from [after:<slug>]
+	Verify      []string `json:"verify,omitempty"`      // Commands that must pass in the worktree, from [verify:<cmd>]
+}
+
+// Text returns the title followed by the description, if any: the task text
+// given to workers and reviewers.
+func (t Task) Text() string {
+	if t.Description == "" {
+		return t.Title
+	}
+	return t.Title + "\n\n" + t.Description
 }
 
 var (
-	modelAnnotation = regexp.MustCompile(`\[model:([^\]]+)\]`)
-	titleAnnotation = regexp.MustCompile(`\[title:([^\]]+)\]`)
+	modelAnnotation  = regexp.MustCompile(`\[model:([^\]]+)\]`)
+	titleAnnotation  = regexp.MustCompile(`\[title:([^\]]+)\]`)
+	afterAnnotation  = regexp.MustCompile(`\[after:([^\]]+)\]`)
+	verifyAnnotation = regexp.MustCompile(`\[verify:([^\]]+)\]`)
 
 	// Matches standard markdown bullets: "- ", "* ", "  - ", etc.
 	bulletPattern = regexp.MustCompile(`^[\s]*[-*]\s+`)
@@ -45,6 +58,9 @@ var (
 //  4. Bullet points under any recognized task section heading
 //  5. Fallback: entire file content as a single task
 //
+// Tasks are returned in dependency order (see OrderByDependencies); an error is
+// returned if an [after:<slug>] annotation names an unknown task or forms a cycle.
+//
 // Supported file formats: any text-based format (.md, .txt, .yaml, .json, etc.)
 // The content is passed through to the AI model which handles format-specific parsing.
 func ParseFile(path string) ([]Task, error) {
@@ -60,7 +76,7 @@ func ParseFile(path string) ([]Task, error) {
 		return nil, err
 	}
 	if len(tasks) > 0 {
-		return tasks, nil
+		return OrderByDependencies(tasks)
 	}
 
 	// Strategy 2: Scan for checkboxes anywhere in the file
@@ -72,7 +88,7 @@ func ParseFile(path string) ([]Task, error) {
 		return nil, err
 	}
 	if len(tasks) > 0 {
-		return tasks, nil
+		return OrderByDependencies(tasks)
 	}
 
 	// Strategy 3: Fallback — entire file as a single task
@@ -262,6 +278,24 @@ func parseFallbackSingleTask(r io.Reader, path string) ([]Task, error) {
 func extractTaskFromLine(title string) *Task {
 	model := ""
 	explicitTitle := ""
+	var deps []string
+
+	for _, m := range afterAnnotation.FindAllStringSubmatch(title, -1) {
+		for _, dep := range strings.Split(m[1], ",") {
+			if slug := toSlug(dep); slug != "" {
+				deps = appendUnique(deps, slug)
+			}
+		}
+	}
+	title = strings.TrimSpace(afterAnnotation.ReplaceAllString(title, ""))
+
+	var verify []string
+	for _, m := range verifyAnnotation.FindAllStringSubmatch(title, -1) {
+		if cmd := strings.TrimSpace(m[1]); cmd != "" {
+			verify = append(verify, cmd)
+		}
+	}
+	title = strings.TrimSpace(verifyAnnotation.ReplaceAllString(title, ""))
 
 	if m := modelAnnotation.FindStringSubmatch(title); m != nil {
 		model = strings.TrimSpace(m[1])
@@ -282,7 +316,109 @@ func extractTaskFromLine(title string) *Task {
 		Description: "",
 		Slug:        toSlug(title),
 		Model:       model,
+		DependsOn:   deps,
+		Verify:      verify,
+	}
+}
+
+// OrderByDependencies returns tasks sorted so that every task appears after the
+// tasks named in its DependsOn list. Independent tasks keep their file order.
+// It returns an error if a dependency names an unknown slug or if the
+// dependencies form a cycle.
+func OrderByDependencies(tasks []Task) ([]Task, error) {
+	bySlug := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		bySlug[t.Slug] = true
+	}
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if !bySlug[dep] {
+				return nil, fmt.Errorf("task %q depends on unknown task %q", t.Slug, dep)
+			}
+		}
+	}
+
+	ordered := make([]Task, 0, len(tasks))
+	placed := make(map[string]bool, len(tasks))
+	remaining := append([]Task(nil), tasks...)
+
+	for len(remaining) > 0 {
+		next := -1
+		for i, t := range remaining {
+			ready := true
+			for _, dep := range t.DependsOn {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, fmt.Errorf("dependency cycle detected: %s", strings.Join(findCycle(remaining), " -> "))
+		}
+		placed[remaining[next].Slug] = true
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return ordered, nil
+}
+
+// findCycle returns the slugs along one dependency cycle among tasks, with the
+// first slug repeated at the end. tasks must contain at least one cycle.
+func findCycle(tasks []Task) []string {
+	deps := make(map[string][]string, len(tasks))
+	for _, t := range tasks {
+		deps[t.Slug] = t.DependsOn
+	}
+
+	visiting := make(map[string]int) // slug -> index in path
+	visited := make(map[string]bool)
+	var path []string
+
+	var visit func(slug string) []string
+	visit = func(slug string) []string {
+		if idx, ok := visiting[slug]; ok {
+			return append(append([]string(nil), path[idx:]...), slug)
+		}
+		if visited[slug] {
+			return nil
+		}
+		visiting[slug] = len(path)
+		path = append(path, slug)
+		for _, dep := range deps[slug] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		delete(visiting, slug)
+		visited[slug] = true
+		return nil
+	}
+
+	for _, t := range tasks {
+		if cycle := visit(t.Slug); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
 	}
+	return append(list, s)
 }
 
 // toSlug converts a human-readable string into a lowercase, hyphen-separated
@@ -3,6 +3,7 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -357,3 +358,99 @@ No structured tasks here.
 		t.Error("expected non-empty description for fallback task")
 	}
 }
+
+func TestParseFile_AfterAnnotation(t *testing.T) {
+	path := writeTempFile(t, "", `## Tasks
+- Write the API client [after:add-user-api]
+- Add user API
+- Document the client [after:write-the-api-client, add-user-api]
+`)
+	tasks, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("got %d tasks; want 3", len(tasks))
+	}
+
+	wantOrder := []string{"add-user-api", "write-the-api-client", "document-the-client"}
+	for i, want := range wantOrder {
+		if tasks[i].Slug != want {
+			t.Errorf("tasks[%d].Slug = %q; want %q", i, tasks[i].Slug, want)
+		}
+	}
+	if tasks[1].Title != "Write the API client" {
+		t.Errorf("tasks[1].Title = %q; want annotation stripped", tasks[1].Title)
+	}
+	if len(tasks[2].DependsOn) != 2 || tasks[2].DependsOn[0] != "write-the-api-client" || tasks[2].DependsOn[1] != "add-user-api" {
+		t.Errorf("tasks[2].DependsOn = %v; want [write-the-api-client add-user-api]", tasks[2].DependsOn)
+	}
+}
+
+func TestParseFile_AfterUnknownTask(t *testing.T) {
+	path := writeTempFile(t, "", `## Tasks
+- Write the client [after:missing-task]
+`)
+	if _, err := ParseFile(path); err == nil {
+		t.Fatal("expected error for unknown dependency, got nil")
+	}
+}
+
+func TestOrderByDependencies_Cycle(t *testing.T) {
+	tasks := []Task{
+		{Slug: "a", DependsOn: []string{"c"}},
+		{Slug: "b", DependsOn: []string{"a"}},
+		{Slug: "c", DependsOn: []string{"b"}},
+		{Slug: "d"},
+	}
+	_, err := OrderByDependencies(tasks)
+	if err == nil {
+		t.Fatal("expected cycle error, got nil")
+	}
+	if !strings.Contains(err.Error(), "a -> c -> b -> a") {
+		t.Errorf("error = %q; want cycle path a -> c -> b -> a", err)
+	}
+}
+
+func TestOrderByDependencies_SelfDependency(t *testing.T) {
+	_, err := OrderByDependencies([]Task{{Slug: "a", DependsOn: []string{"a"}}})
+	if err == nil {
+		t.Fatal("expected cycle error for self-dependency, got nil")
+	}
+}
+
+func TestOrderByDependencies_KeepsFileOrder(t *testing.T) {
+	tasks := []Task{{Slug: "one"}, {Slug: "two"}, {Slug: "three"}}
+	got, err := OrderByDependencies(tasks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range tasks {
+		if got[i].Slug != tasks[i].Slug {
+			t.Errorf("got[%d].Slug = %q; want %q", i, got[i].Slug, tasks[i].Slug)
+		}
+	}
+}
+
+func TestParseFile_VerifyAnnotation(t *testing.T) {
+	path := writeTempFile(t, "", `## Tasks
+- Add login page [verify:make lint] [verify:go test ./auth/...] [model:claude-opus-4-6]
+- Fix typo
+`)
+	tasks, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tasks[0].Title != "Add login page" {
+		t.Errorf("tasks[0].Title = %q; want %q", tasks[0].Title, "Add login page")
+	}
+	if len(tasks[0].Verify) != 2 || tasks[0].Verify[0] != "make lint" || tasks[0].Verify[1] != "go test ./auth/..." {
+		t.Errorf("tasks[0].Verify = %q; want [make lint, go test ./auth/...]", tasks[0].Verify)
+	}
+	if tasks[0].Model != "claude-opus-4-6" {
+		t.Errorf("tasks[0].Model = %q; want claude-opus-4-6", tasks[0].Model)
+	}
+	if len(tasks[1].Verify) != 0 {
+		t.Errorf("tasks[1].Verify = %q; want none", tasks[1].Verify)
+	}
+}
@@ -0,0 +1,9 @@
+// Package proc stops agent and verification commands together with every
+// process they spawn.
+package proc
+
+import "time"
+
+// Grace is how long a cancelled command's process group has to exit after
+// the polite signal before it is killed outright.
+const Grace = 5 * time.Second
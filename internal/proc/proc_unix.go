@@ -0,0 +1,32 @@
+//go:build !windows
+
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// KillGroupOnCancel runs cmd in its own process group. When the command's
+// context is done the whole group receives SIGTERM, followed by SIGKILL after
+// grace, so helpers spawned by an agent CLI do not outlive it. It does nothing
+// for commands not created with exec.CommandContext.
+func KillGroupOnCancel(cmd *exec.Cmd, grace time.Duration) {
+	if cmd.Cancel == nil {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		err := syscall.Kill(pgid, syscall.SIGTERM)
+		time.AfterFunc(grace, func() { _ = syscall.Kill(pgid, syscall.SIGKILL) })
+		return err
+	}
+	// Orphaned children may hold the output pipes open; stop waiting on them
+	// shortly after the group has been killed.
+	cmd.WaitDelay = grace + time.Second
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package proc
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestKillGroupOnCancel_StopsChildren(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The background sleep inherits stdout; unless it is killed too, Wait
+	// blocks until WaitDelay expires.
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30 & wait")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	KillGroupOnCancel(cmd, 5*time.Second)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_ = cmd.Wait()
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Wait took %s; child process outlived cancellation", elapsed)
+	}
+}
+
+func TestKillGroupOnCancel_IgnoresPlainCommands(t *testing.T) {
+	cmd := exec.Command("true")
+	KillGroupOnCancel(cmd, time.Second)
+	if cmd.Cancel != nil || cmd.SysProcAttr != nil {
+		t.Error("expected a command without context to be left alone")
+	}
+	if err := cmd.Run(); err != nil {
+		t.Errorf("run: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package proc
+
+import (
+	"os/exec"
+	"time"
+)
+
+// KillGroupOnCancel keeps exec's default of killing the process when the
+// context is done; Windows has no process groups to signal. It only bounds how
+// long Wait blocks on output pipes held by surviving children.
+func KillGroupOnCancel(cmd *exec.Cmd, grace time.Duration) {
+	if cmd.Cancel == nil {
+		return
+	}
+	cmd.WaitDelay = grace
+}
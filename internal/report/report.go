@@ -0,0 +1,195 @@
+// Package report writes machine-readable records of a run — JSON and JUnit
+// XML — for CI pipelines.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+// Task statuses, matching the worktree manifest.
+const (
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+	StatusCancelled = "cancelled"
+)
+
+// Run is the record of one mochi run.
+type Run struct {
+	RunID     string    `json:"run_id,omitempty"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Duration  float64   `json:"duration_seconds"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Tasks     []Task    `json:"tasks"`
+}
+
+// Task is the record of one task of a run.
+type Task struct {
+	Slug         string          `json:"slug"`
+	Title        string          `json:"title"`
+	Model        string          `json:"model"`
+	Status       string          `json:"status"` // done | failed | skipped | cancelled
+	Success      bool            `json:"success"`
+	Error        string          `json:"error,omitempty"`
+	Iterations   int             `json:"iterations"`
+	Duration     float64         `json:"duration_seconds"`
+	LogPath      string          `json:"log_path,omitempty"`
+	Branch       string          `json:"branch,omitempty"`
+	PRURL        string          `json:"pr_url,omitempty"`
+	Output       string          `json:"output,omitempty"` // file written or issue filed by the output mode
+	Verification []verify.Result `json:"verification,omitempty"`
+	Review       *Review         `json:"review,omitempty"`
+}
+
+// Review is the last reviewer verdict of a task.
+type Review struct {
+	Feedback string          `json:"feedback,omitempty"` // reviewer notes, merged across reviewers
+	Verdict  json.RawMessage `json:"verdict,omitempty"`  // structured verdict with --review-format json
+}
+
+// Finish stamps the run's end time and duration and counts its outcomes.
+func (r *Run) Finish(finished time.Time) {
+	r.Finished = finished
+	r.Duration = finished.Sub(r.Started).Seconds()
+	r.Succeeded, r.Failed = 0, 0
+	for _, t := range r.Tasks {
+		if t.Success {
+			r.Succeeded++
+		} else {
+			r.Failed++
+		}
+	}
+}
+
+// WriteJSON writes the run record as indented JSON to path.
+func WriteJSON(path string, r Run) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("report: cannot encode run: %w", err)
+	}
+	return write(path, append(data, '\n'))
+}
+
+// JUnit XML, as understood by common CI systems: one test suite for the run,
+// one test case per task.
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the run record as JUnit XML to path. Failed tasks become
+// failures carrying their verification output and reviewer feedback; skipped
+// and cancelled tasks become skipped test cases.
+func WriteJUnit(path string, r Run) error {
+	suite := junitSuite{
+		Name:      "mochi",
+		Tests:     len(r.Tasks),
+		Time:      seconds(r.Duration),
+		Timestamp: r.Started.Format("2006-01-02T15:04:05"),
+	}
+	for _, t := range r.Tasks {
+		tc := junitCase{
+			Name:      t.Slug,
+			Classname: "mochi." + t.Model,
+			Time:      seconds(t.Duration),
+			SystemOut: systemOut(t),
+		}
+		switch {
+		case t.Success:
+		case t.Status == StatusSkipped || t.Status == StatusCancelled:
+			tc.Skipped = &junitMessage{Message: t.Error}
+			suite.Skipped++
+		default:
+			tc.Failure = &junitMessage{Message: t.Error, Body: failureDetail(t)}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	data, err := xml.MarshalIndent(junitSuites{Suites: []junitSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("report: cannot encode JUnit XML: %w", err)
+	}
+	return write(path, append([]byte(xml.Header), append(data, '\n')...))
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+func systemOut(t Task) string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("title", t.Title)
+	add("branch", t.Branch)
+	add("log", t.LogPath)
+	add("pr", t.PRURL)
+	add("output", t.Output)
+	lines = append(lines, fmt.Sprintf("iterations: %d", t.Iterations))
+	return strings.Join(lines, "\n")
+}
+
+func failureDetail(t Task) string {
+	var b strings.Builder
+	for _, v := range t.Verification {
+		if v.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "verification failed: %s\n%s\n\n", v.Command, strings.TrimSpace(v.Output))
+	}
+	if t.Review != nil && t.Review.Feedback != "" {
+		fmt.Fprintf(&b, "reviewer feedback:\n%s\n", strings.TrimSpace(t.Review.Feedback))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func write(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("report: cannot create %q: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("report: cannot write %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+func sampleRun() Run {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	run := Run{
+		Started: started,
+		Tasks: []Task{
+			{Slug: "add-auth", Model: "fake-1", Status: StatusDone, Success: true, Iterations: 2, Duration: 12.5, PRURL: "https://example.com/pr/1"},
+			{Slug: "fix-login", Model: "fake-1", Status: StatusFailed, Error: "verification failed", Iterations: 3,
+				Verification: []verify.Result{{Command: "go test ./...", Passed: false, Output: "FAIL login_test.go"}},
+				Review:       &Review{Feedback: "Tests still fail"}},
+			{Slug: "docs", Model: "fake-1", Status: StatusSkipped, Error: `skipped: prerequisite "fix-login" did not succeed`},
+		},
+	}
+	run.Finish(started.Add(90 * time.Second))
+	return run
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "run.json")
+	if err := WriteJSON(path, sampleRun()); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var got Run
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Succeeded != 1 || got.Failed != 2 || got.Duration != 90 || len(got.Tasks) != 3 {
+		t.Errorf("run = %+v", got)
+	}
+	if got.Tasks[1].Review == nil || got.Tasks[1].Verification[0].Command != "go test ./..." {
+		t.Errorf("failed task lost its details: %+v", got.Tasks[1])
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if err := WriteJUnit(path, sampleRun()); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var got junitSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	suite := got.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 || suite.Time != "90.000" {
+		t.Errorf("suite = %+v", suite)
+	}
+	failed := suite.Cases[1]
+	if failed.Failure == nil || !strings.Contains(failed.Failure.Body, "FAIL login_test.go") || !strings.Contains(failed.Failure.Body, "Tests still fail") {
+		t.Errorf("failure = %+v", failed.Failure)
+	}
+	if suite.Cases[0].Failure != nil || !strings.Contains(suite.Cases[0].SystemOut, "pr: https://example.com/pr/1") {
+		t.Errorf("passing case = %+v", suite.Cases[0])
+	}
+	if suite.Cases[2].Skipped == nil {
+		t.Errorf("skipped case = %+v", suite.Cases[2])
+	}
+}
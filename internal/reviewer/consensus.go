@@ -0,0 +1,178 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Policy turns the decisions of several reviewers into one verdict.
+type Policy string
+
+const (
+	// PolicyUnanimous finishes the task only when every reviewer says DONE.
+	PolicyUnanimous Policy = "unanimous"
+	// PolicyMajority finishes the task when more than half say DONE.
+	PolicyMajority Policy = "majority"
+	// PolicyAnyBlocking finishes the task unless a reviewer raises a blocking
+	// issue. A text-format RETRY counts as blocking; a JSON verdict blocks
+	// only when it lists blocking_issues.
+	PolicyAnyBlocking Policy = "any-blocking"
+)
+
+// ParsePolicy validates a policy name. An empty name selects PolicyUnanimous.
+func ParsePolicy(name string) (Policy, error) {
+	switch p := Policy(name); p {
+	case "":
+		return PolicyUnanimous, nil
+	case PolicyUnanimous, PolicyMajority, PolicyAnyBlocking:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown review policy %q (want unanimous, majority or any-blocking)", name)
+}
+
+// Vote is one reviewer's decision, or the error that kept it from deciding.
+type Vote struct {
+	Model    string
+	Decision Decision
+	Err      error
+}
+
+// ReviewAll runs one review per model concurrently, with opts shared between
+// them, and returns the votes in the order of models. When there is more than
+// one model, each reviewer's log name is tagged with its model.
+func ReviewAll(ctx context.Context, opts Options, models []string) []Vote {
+	votes := make([]Vote, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			o := opts
+			o.Model = model
+			if len(models) > 1 {
+				o.LogTag = slugify(model)
+			}
+			d, err := Review(ctx, o)
+			votes[i] = Vote{Model: model, Decision: d, Err: err}
+		}(i, model)
+	}
+	wg.Wait()
+	return votes
+}
+
+// Combine applies policy to votes. Reviewers that failed are left out of the
+// count; Combine returns an error only when none of them produced a decision.
+// A single successful vote is returned as-is. Otherwise the feedback of every
+// reviewer is merged under a heading per model, and structured verdicts are
+// merged into one.
+func Combine(policy Policy, votes []Vote) (Decision, error) {
+	var ok []Vote
+	var errs []string
+	for _, v := range votes {
+		if v.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", v.Model, v.Err))
+			continue
+		}
+		ok = append(ok, v)
+	}
+	if len(ok) == 0 {
+		return Decision{}, fmt.Errorf("all reviewers failed: %s", strings.Join(errs, "; "))
+	}
+	if len(votes) == 1 {
+		return ok[0].Decision, nil
+	}
+
+	done := 0
+	blocking := false
+	for _, v := range ok {
+		if v.Decision.Done {
+			done++
+		}
+		if isBlocking(v.Decision) {
+			blocking = true
+		}
+	}
+
+	var d Decision
+	switch policy {
+	case PolicyMajority:
+		d.Done = done*2 > len(ok)
+	case PolicyAnyBlocking:
+		d.Done = !blocking
+	default:
+		d.Done = done == len(ok)
+	}
+
+	var feedback, raw strings.Builder
+	for _, v := range votes {
+		if v.Err != nil {
+			fmt.Fprintf(&feedback, "### %s — unavailable\n\n%v\n\n", v.Model, v.Err)
+			continue
+		}
+		verdict := "RETRY"
+		if v.Decision.Done {
+			verdict = "DONE"
+		}
+		fmt.Fprintf(&feedback, "### %s — %s\n\n", v.Model, verdict)
+		if fb := strings.TrimSpace(v.Decision.Feedback); fb != "" {
+			feedback.WriteString(fb + "\n\n")
+		}
+		fmt.Fprintf(&raw, "=== %s ===\n%s\n", v.Model, v.Decision.Raw)
+	}
+	fmt.Fprintf(&feedback, "_Consensus (%s): %d of %d reviewers said DONE._", policy, done, len(ok))
+	d.Feedback = feedback.String()
+	d.Raw = raw.String()
+	d.Verdict = mergeVerdicts(ok, d.Done)
+	return d, nil
+}
+
+func isBlocking(d Decision) bool {
+	if d.Verdict != nil {
+		return len(d.Verdict.Blocking) > 0
+	}
+	return !d.Done
+}
+
+// mergeVerdicts combines the structured verdicts among votes, or returns nil
+// when no reviewer returned one. Scores are averaged per criterion; issues and
+// comments are attributed to the reviewer that raised them.
+func mergeVerdicts(votes []Vote, done bool) *Verdict {
+	merged := Verdict{Done: done}
+	totals := map[string]int{}
+	counts := map[string]int{}
+	found := false
+	for _, vote := range votes {
+		v := vote.Decision.Verdict
+		if v == nil {
+			continue
+		}
+		found = true
+		if v.Summary != "" {
+			merged.Summary += fmt.Sprintf("%s: %s ", vote.Model, v.Summary)
+		}
+		for c, s := range v.Scores {
+			totals[c] += s
+			counts[c]++
+		}
+		for _, issue := range v.Blocking {
+			merged.Blocking = append(merged.Blocking, fmt.Sprintf("%s (%s)", issue, vote.Model))
+		}
+		for _, c := range v.Comments {
+			c.Body = fmt.Sprintf("%s (%s)", c.Body, vote.Model)
+			merged.Comments = append(merged.Comments, c)
+		}
+	}
+	if !found {
+		return nil
+	}
+	merged.Summary = strings.TrimSpace(merged.Summary)
+	if len(totals) > 0 {
+		merged.Scores = make(map[string]int, len(totals))
+		for c, total := range totals {
+			merged.Scores[c] = (total + counts[c]/2) / counts[c]
+		}
+	}
+	return &merged
+}
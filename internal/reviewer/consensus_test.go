@@ -0,0 +1,130 @@
+package reviewer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func done(model string) Vote {
+	return Vote{Model: model, Decision: Decision{Done: true}}
+}
+
+func retry(model, feedback string) Vote {
+	return Vote{Model: model, Decision: Decision{Feedback: feedback}}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if p, err := ParsePolicy(""); err != nil || p != PolicyUnanimous {
+		t.Errorf("ParsePolicy(\"\") = %q, %v; want unanimous", p, err)
+	}
+	if p, err := ParsePolicy("any-blocking"); err != nil || p != PolicyAnyBlocking {
+		t.Errorf("ParsePolicy(any-blocking) = %q, %v", p, err)
+	}
+	if _, err := ParsePolicy("quorum"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestCombine_Policies(t *testing.T) {
+	votes := []Vote{done("claude"), done("gemini"), retry("opus", "missing tests")}
+	cases := []struct {
+		policy Policy
+		want   bool
+	}{
+		{PolicyUnanimous, false},
+		{PolicyMajority, true},
+		{PolicyAnyBlocking, false}, // a text RETRY counts as blocking
+	}
+	for _, c := range cases {
+		d, err := Combine(c.policy, votes)
+		if err != nil {
+			t.Fatalf("%s: %v", c.policy, err)
+		}
+		if d.Done != c.want {
+			t.Errorf("%s: Done = %v; want %v", c.policy, d.Done, c.want)
+		}
+	}
+}
+
+func TestCombine_AnyBlockingIgnoresNonBlockingRetry(t *testing.T) {
+	votes := []Vote{
+		{Model: "claude", Decision: Decision{Verdict: &Verdict{Comments: []Comment{{File: "a.go", Body: "nit"}}}}},
+		{Model: "gemini", Decision: Decision{Done: true, Verdict: &Verdict{Done: true}}},
+	}
+	d, err := Combine(PolicyAnyBlocking, votes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Done {
+		t.Error("a verdict without blocking issues should not block")
+	}
+
+	votes[1].Decision.Verdict.Blocking = []string{"breaks the build"}
+	if d, _ := Combine(PolicyAnyBlocking, votes); d.Done {
+		t.Error("a blocking issue should block")
+	}
+}
+
+func TestCombine_MergesFeedback(t *testing.T) {
+	votes := []Vote{
+		retry("claude", "add tests"),
+		done("gemini"),
+		{Model: "opus", Err: errors.New("timed out")},
+	}
+	d, err := Combine(PolicyMajority, votes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The failed reviewer is not counted: 1 of 2 is not a majority.
+	if d.Done {
+		t.Error("expected RETRY")
+	}
+	for _, want := range []string{
+		"### claude — RETRY\n\nadd tests",
+		"### gemini — DONE",
+		"### opus — unavailable\n\ntimed out",
+		"1 of 2 reviewers said DONE",
+	} {
+		if !strings.Contains(d.Feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, d.Feedback)
+		}
+	}
+}
+
+func TestCombine_SingleVoteUnchanged(t *testing.T) {
+	d, err := Combine(PolicyUnanimous, []Vote{retry("claude", "add tests")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Feedback != "add tests" {
+		t.Errorf("Feedback = %q; want the reviewer's own feedback", d.Feedback)
+	}
+}
+
+func TestCombine_AllFailed(t *testing.T) {
+	_, err := Combine(PolicyUnanimous, []Vote{{Model: "claude", Err: errors.New("boom")}})
+	if err == nil || !strings.Contains(err.Error(), "claude: boom") {
+		t.Errorf("err = %v; want the reviewer error", err)
+	}
+}
+
+func TestCombine_MergesVerdicts(t *testing.T) {
+	votes := []Vote{
+		{Model: "claude", Decision: Decision{Done: true, Verdict: &Verdict{Done: true, Scores: map[string]int{"tests": 4}}}},
+		{Model: "gemini", Decision: Decision{Verdict: &Verdict{Scores: map[string]int{"tests": 1}, Blocking: []string{"no tests"}}}},
+	}
+	d, err := Combine(PolicyUnanimous, votes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Verdict == nil {
+		t.Fatal("expected a merged verdict")
+	}
+	if d.Verdict.Scores["tests"] != 3 {
+		t.Errorf("tests score = %d; want rounded average 3", d.Verdict.Scores["tests"])
+	}
+	if len(d.Verdict.Blocking) != 1 || d.Verdict.Blocking[0] != "no tests (gemini)" {
+		t.Errorf("Blocking = %v", d.Verdict.Blocking)
+	}
+}
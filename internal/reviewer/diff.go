@@ -0,0 +1,124 @@
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DefaultMaxDiffBytes bounds the diff included in a review prompt when
+// DiffOptions.MaxBytes is zero.
+const DefaultMaxDiffBytes = 20000
+
+// DiffOptions limits how much of the task branch's changes the reviewer sees.
+type DiffOptions struct {
+	MaxBytes int      // diff bytes included in the prompt (0 = DefaultMaxDiffBytes)
+	Exclude  []string // pathspec patterns left out of the diff, e.g. "*.lock"
+}
+
+// Changes describes what a task branch changed relative to its base.
+type Changes struct {
+	Commits     string   // one line per commit, oldest first
+	Stat        string   // git diff --stat
+	Diff        string   // unified diff of the files that fit the size limit
+	Omitted     []string // files whose diff was left out for size
+	Uncommitted string   // git status --short of the worktree
+}
+
+// Empty reports whether the branch has no commits, no diff and no pending edits.
+func (c Changes) Empty() bool {
+	return c.Commits == "" && c.Diff == "" && len(c.Omitted) == 0 && c.Uncommitted == ""
+}
+
+// CollectChanges gathers the commits and diff of the worktree since it forked
+// from base, so commits added to base in the meantime are not shown as
+// reverted. The diff covers the working tree, so edits the worker forgot to
+// commit are reviewed too and also listed under Uncommitted.
+func CollectChanges(ctx context.Context, worktreePath, base string, opts DiffOptions) (Changes, error) {
+	var c Changes
+	fork, err := git(ctx, worktreePath, "merge-base", base, "HEAD")
+	if err != nil {
+		return c, fmt.Errorf("cannot find where the branch forked from %s: %w", base, err)
+	}
+
+	pathspec := []string{"--", "."}
+	for _, pattern := range opts.Exclude {
+		pathspec = append(pathspec, ":(exclude)"+pattern)
+	}
+
+	if c.Commits, err = git(ctx, worktreePath, "log", "--reverse", "--format=%h %s", fork+"..HEAD"); err != nil {
+		return c, fmt.Errorf("cannot list commits since %s: %w", base, err)
+	}
+	if c.Stat, err = git(ctx, worktreePath, append([]string{"diff", "--stat", fork}, pathspec...)...); err != nil {
+		return c, fmt.Errorf("cannot diff against %s: %w", base, err)
+	}
+	diff, err := git(ctx, worktreePath, append([]string{"diff", fork}, pathspec...)...)
+	if err != nil {
+		return c, fmt.Errorf("cannot diff against %s: %w", base, err)
+	}
+	if c.Uncommitted, err = git(ctx, worktreePath, "status", "--short"); err != nil {
+		return c, fmt.Errorf("cannot read worktree status: %w", err)
+	}
+
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDiffBytes
+	}
+	c.Diff, c.Omitted = limitDiff(diff, maxBytes)
+	return c, nil
+}
+
+// limitDiff keeps whole per-file sections of diff, in order, as long as they
+// fit in maxBytes, and returns the paths of the sections it left out. A single
+// oversized file does not hide the smaller ones after it.
+func limitDiff(diff string, maxBytes int) (string, []string) {
+	if len(diff) <= maxBytes {
+		return diff, nil
+	}
+	var kept strings.Builder
+	var omitted []string
+	for _, section := range splitFileSections(diff) {
+		if kept.Len()+len(section) > maxBytes {
+			omitted = append(omitted, sectionPath(section))
+			continue
+		}
+		kept.WriteString(section)
+	}
+	return strings.TrimRight(kept.String(), "\n"), omitted
+}
+
+// sectionPath returns the b/ path from a "diff --git a/x b/x" header.
+func sectionPath(section string) string {
+	header, _, _ := strings.Cut(section, "\n")
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+3:]
+	}
+	return strings.TrimPrefix(header, "diff --git ")
+}
+
+// splitFileSections splits a unified diff before each "diff --git" header.
+func splitFileSections(diff string) []string {
+	var sections []string
+	for {
+		next := strings.Index(diff[1:], "\ndiff --git ")
+		if next < 0 {
+			return append(sections, diff)
+		}
+		sections = append(sections, diff[:next+2])
+		diff = diff[next+2:]
+	}
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,180 @@
+package reviewer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+// setupBranch creates a repo whose "task" branch adds two files on top of main.
+func setupBranch(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-b", "main")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	write("README.md", "# test\n")
+	run("add", ".")
+	run("commit", "-m", "initial")
+
+	run("checkout", "-b", "task")
+	write("nav.go", "package nav\n\nfunc Open() {}\n")
+	run("add", ".")
+	run("commit", "-m", "Add nav")
+	write("deps.lock", strings.Repeat("hash\n", 50))
+	run("add", ".")
+	run("commit", "-m", "Update lockfile")
+	return dir
+}
+
+func TestCollectChanges(t *testing.T) {
+	dir := setupBranch(t)
+
+	c, err := CollectChanges(context.Background(), dir, "main", DiffOptions{})
+	if err != nil {
+		t.Fatalf("CollectChanges failed: %v", err)
+	}
+	lines := strings.Split(c.Commits, "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "Add nav") || !strings.HasSuffix(lines[1], "Update lockfile") {
+		t.Errorf("Commits = %q; want both commits, oldest first", c.Commits)
+	}
+	if !strings.Contains(c.Diff, "+func Open() {}") || !strings.Contains(c.Diff, "deps.lock") {
+		t.Errorf("Diff missing changes:\n%s", c.Diff)
+	}
+	if c.Uncommitted != "" {
+		t.Errorf("Uncommitted = %q; want clean", c.Uncommitted)
+	}
+}
+
+func TestCollectChanges_BaseMovedOn(t *testing.T) {
+	dir := setupBranch(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	run("checkout", "-q", "main")
+	if err := os.WriteFile(filepath.Join(dir, "upstream.go"), []byte("package upstream\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-qm", "Upstream work")
+	run("checkout", "-q", "task")
+
+	c, err := CollectChanges(context.Background(), dir, "main", DiffOptions{})
+	if err != nil {
+		t.Fatalf("CollectChanges failed: %v", err)
+	}
+	if strings.Contains(c.Diff, "upstream.go") || strings.Contains(c.Stat, "upstream.go") {
+		t.Errorf("upstream commit shown as a change of the task branch:\n%s", c.Stat)
+	}
+	if !strings.Contains(c.Diff, "+func Open() {}") {
+		t.Errorf("Diff missing the task's changes:\n%s", c.Diff)
+	}
+}
+
+func TestCollectChanges_ExcludeAndUncommitted(t *testing.T) {
+	dir := setupBranch(t)
+	if err := os.WriteFile(filepath.Join(dir, "nav.go"), []byte("package nav\n\nfunc Close() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := CollectChanges(context.Background(), dir, "main", DiffOptions{Exclude: []string{"*.lock"}})
+	if err != nil {
+		t.Fatalf("CollectChanges failed: %v", err)
+	}
+	if strings.Contains(c.Diff, "deps.lock") || strings.Contains(c.Stat, "deps.lock") {
+		t.Errorf("excluded file still present:\n%s\n%s", c.Stat, c.Diff)
+	}
+	if !strings.Contains(c.Diff, "+func Close() {}") {
+		t.Errorf("uncommitted edit missing from diff:\n%s", c.Diff)
+	}
+	if !strings.Contains(c.Uncommitted, "nav.go") {
+		t.Errorf("Uncommitted = %q; want nav.go", c.Uncommitted)
+	}
+}
+
+func TestCollectChanges_OmitsOversizedFiles(t *testing.T) {
+	dir := setupBranch(t)
+
+	c, err := CollectChanges(context.Background(), dir, "main", DiffOptions{MaxBytes: 200})
+	if err != nil {
+		t.Fatalf("CollectChanges failed: %v", err)
+	}
+	if len(c.Omitted) != 1 || c.Omitted[0] != "deps.lock" {
+		t.Errorf("Omitted = %v; want [deps.lock]", c.Omitted)
+	}
+	if !strings.Contains(c.Diff, "+func Open() {}") {
+		t.Errorf("small file should still be shown:\n%s", c.Diff)
+	}
+}
+
+func TestCollectChanges_UnknownBase(t *testing.T) {
+	dir := setupBranch(t)
+	if _, err := CollectChanges(context.Background(), dir, "no-such-ref", DiffOptions{}); err == nil {
+		t.Error("expected an error for an unknown base")
+	}
+}
+
+func TestBuildReviewPrompt_IncludesChangesAndVerification(t *testing.T) {
+	opts := Options{
+		Task:         "Add nav",
+		WorkerOutput: "All done!",
+		Iteration:    1,
+		MaxIter:      2,
+		Base:         "main",
+		Verification: []verify.Result{{Command: "go test ./...", Passed: true, Output: "ok  nav"}},
+	}
+	changes := Changes{Commits: "abc123 Add nav", Diff: "diff --git a/nav.go b/nav.go\n+func Open() {}"}
+
+	prompt, err := buildReviewPrompt(opts, changes, nil)
+	if err != nil {
+		t.Fatalf("buildReviewPrompt failed: %v", err)
+	}
+	for _, want := range []string{"against main", "abc123 Add nav", "+func Open() {}", "$ go test ./... (passed)", "ok  nav", "not the worker's description"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	prompt, err = buildReviewPrompt(opts, Changes{}, nil)
+	if err != nil {
+		t.Fatalf("buildReviewPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "No commits and no file changes were found.") {
+		t.Errorf("prompt should flag an empty branch:\n%s", prompt)
+	}
+}
+
+func TestBuildReviewPrompt_WithoutBase(t *testing.T) {
+	prompt, err := buildReviewPrompt(Options{Task: "Add nav", WorkerOutput: "done", Iteration: 1, MaxIter: 1}, Changes{}, nil)
+	if err != nil {
+		t.Fatalf("buildReviewPrompt failed: %v", err)
+	}
+	if strings.Contains(prompt, "CHANGES ON THE TASK BRANCH") {
+		t.Errorf("prompt should not mention changes without a base:\n%s", prompt)
+	}
+}
@@ -5,16 +5,20 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/agent"
+	"github.com/thisguymartin/ai-forge/internal/proc"
+	"github.com/thisguymartin/ai-forge/internal/verify"
 )
 
 // Options configures a single reviewer invocation.
 type Options struct {
 	WorktreePath string
+	Slug         string // names the reviewer log
 	Task         string
 	Model        string
 	WorkerOutput string
@@ -23,6 +27,21 @@ type Options struct {
 	Timeout      int
 	Verbose      bool
 	LogDir       string
+	LogTag       string          // appended to the log name to tell concurrent reviewers apart
+	Providers    *agent.Registry // resolves Model; nil means the registered providers
+
+	// Base is the ref the task branch started from. When set, the prompt
+	// includes the branch's commits and diff against it.
+	Base string
+	Diff DiffOptions
+
+	// Verification holds the results of this iteration's verification commands.
+	Verification []verify.Result
+
+	// Format selects the verdict format: "" for the DONE/RETRY line, or
+	// FormatJSON for a structured Verdict scored against Rubric.
+	Format string
+	Rubric []string
 }
 
 // Decision represents the reviewer's verdict.
@@ -30,6 +49,9 @@ type Decision struct {
 	Done     bool
 	Feedback string
 	Raw      string
+
+	// Verdict is set when the reviewer returned a structured JSON verdict.
+	Verdict *Verdict
 }
 
 const reviewPromptTmpl = `You are a code reviewer evaluating the output of an AI coding agent.
@@ -39,9 +61,60 @@ Task the worker was asked to complete:
 
 Worker's output (iteration {{.Iteration}} of {{.MaxIter}}):
 {{.WorkerOutput}}
+{{- if .HasChanges}}
+
+=== CHANGES ON THE TASK BRANCH (against {{.Base}}) ===
+{{- if .ChangesError}}
+
+(changes unavailable: {{.ChangesError}})
+{{- else if .Changes.Empty}}
+
+No commits and no file changes were found.
+{{- else}}
+{{- if .Changes.Commits}}
+
+## Commits:
+{{.Changes.Commits}}
+{{- end}}
+{{- if .Changes.Uncommitted}}
+
+## Uncommitted changes:
+{{.Changes.Uncommitted}}
+{{- end}}
+{{- if .Changes.Stat}}
+
+## Files changed:
+{{.Changes.Stat}}
+{{- end}}
+{{- if .Changes.Diff}}
+
+## Diff{{if .Changes.Omitted}} (left out for size: {{join .Changes.Omitted ", "}}){{end}}:
+` + "```diff" + `
+{{.Changes.Diff}}
+` + "```" + `
+{{- end}}
+{{- end}}
+=== END CHANGES ===
+{{- end}}
+{{- if .Verification}}
+
+=== VERIFICATION COMMANDS ===
+{{- range .Verification}}
+
+$ {{.Command}} ({{if .Passed}}passed{{else}}FAILED{{end}})
+{{.Output}}
+{{- end}}
+=== END VERIFICATION ===
+{{- end}}
 
 Your job:
-1. Evaluate whether the task has been completed correctly and completely.
+1. Evaluate whether the task has been completed correctly and completely.{{if .HasChanges}}
+   Judge the changes above, not the worker's description of them.{{end}}
+{{- if .Schema}}
+2. {{.Schema}}
+
+Your verdict (JSON):
+{{- else}}
 2. Respond with EXACTLY one of:
    - The single word: DONE
    - A line starting with: RETRY: <your feedback>
@@ -52,27 +125,47 @@ Rules:
 - Be specific in your feedback so the worker can address it.
 - Do not include any other text before or after your verdict.
 
-Your verdict:`
+Your verdict:
+{{- end}}`
 
 type reviewPromptData struct {
 	Task         string
 	WorkerOutput string
 	Iteration    int
 	MaxIter      int
+
+	HasChanges   bool
+	Base         string
+	Changes      Changes
+	ChangesError string
+	Verification []verify.Result
+	Schema       string
 }
 
-// Review invokes the reviewer model and returns its decision.
-func Review(opts Options) (Decision, error) {
-	prompt, err := buildReviewPrompt(opts)
+// maxVerifyOutput bounds the output of each verification command in the prompt.
+const maxVerifyOutput = 1500
+
+// Review invokes the reviewer model and returns its decision. Cancelling ctx
+// stops the reviewer process.
+func Review(ctx context.Context, opts Options) (Decision, error) {
+	var changes Changes
+	var changesErr error
+	if opts.Base != "" {
+		changes, changesErr = CollectChanges(ctx, opts.WorktreePath, opts.Base, opts.Diff)
+	}
+
+	prompt, err := buildReviewPrompt(opts, changes, changesErr)
 	if err != nil {
 		return Decision{}, fmt.Errorf("reviewer: build prompt: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opts.Timeout)*time.Second)
 	defer cancel()
 
-	cmd := buildCommand(ctx, opts.Model, prompt)
+	provider := opts.Providers.For(opts.Model)
+	cmd := provider.BuildCommand(ctx, opts.Model, prompt)
 	cmd.Dir = opts.WorktreePath
+	proc.KillGroupOnCancel(cmd, proc.Grace)
 
 	var outBuf bytes.Buffer
 	if opts.Verbose {
@@ -88,18 +181,34 @@ func Review(opts Options) (Decision, error) {
 	raw := outBuf.String()
 
 	if opts.LogDir != "" {
-		logPath := filepath.Join(opts.LogDir, fmt.Sprintf("%s-reviewer-iter%d.log", slugify(opts.Task), opts.Iteration))
+		name := LogName(opts.Slug, opts.Iteration)
+		if opts.LogTag != "" {
+			name += "-" + opts.LogTag
+		}
+		logPath := filepath.Join(opts.LogDir, name+".log")
 		_ = os.WriteFile(logPath, []byte(raw), 0644)
 	}
 
+	if ctx.Err() == context.Canceled {
+		return Decision{Raw: raw}, fmt.Errorf("reviewer cancelled: %w", context.Canceled)
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		return Decision{Raw: raw}, fmt.Errorf("reviewer timed out after %ds", opts.Timeout)
 	}
-	if runErr != nil {
+	if !provider.Succeeded(raw, runErr) {
+		if runErr == nil {
+			return Decision{Raw: raw}, fmt.Errorf("reviewer (%s) reported an unsuccessful run", provider.Name())
+		}
 		return Decision{Raw: raw}, fmt.Errorf("reviewer exited with error: %w", runErr)
 	}
 
-	return parseDecision(raw), nil
+	output := provider.ParseOutput(raw)
+	if opts.Format == FormatJSON {
+		if v, ok := parseVerdict(output); ok {
+			return decisionFromVerdict(v, output), nil
+		}
+	}
+	return parseDecision(output), nil
 }
 
 // parseDecision scans stdout for the first DONE or RETRY: line.
@@ -120,35 +229,71 @@ func parseDecision(output string) Decision {
 	return Decision{Done: false, Feedback: strings.TrimSpace(output), Raw: output}
 }
 
-func buildReviewPrompt(opts Options) (string, error) {
-	tmpl, err := template.New("review").Parse(reviewPromptTmpl)
+func buildReviewPrompt(opts Options, changes Changes, changesErr error) (string, error) {
+	tmpl, err := template.New("review").Funcs(template.FuncMap{"join": strings.Join}).Parse(reviewPromptTmpl)
 	if err != nil {
 		return "", err
 	}
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, reviewPromptData{
+
+	data := reviewPromptData{
 		Task:         opts.Task,
 		WorkerOutput: truncate(opts.WorkerOutput, 4000),
 		Iteration:    opts.Iteration,
 		MaxIter:      opts.MaxIter,
-	}); err != nil {
+		HasChanges:   opts.Base != "",
+		Base:         opts.Base,
+		Changes:      changes,
+	}
+	if changesErr != nil {
+		data.ChangesError = changesErr.Error()
+	}
+	for _, r := range opts.Verification {
+		r.Output = tailString(strings.TrimSpace(r.Output), maxVerifyOutput)
+		data.Verification = append(data.Verification, r)
+	}
+	if opts.Format == FormatJSON {
+		rubric := opts.Rubric
+		if len(rubric) == 0 {
+			rubric = DefaultRubric
+		}
+		schema, err := template.New("schema").Parse(verdictSchemaTmpl)
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		if err := schema.Execute(&sb, rubric); err != nil {
+			return "", err
+		}
+		data.Schema = sb.String()
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func buildCommand(ctx context.Context, model, prompt string) *exec.Cmd {
-	if strings.HasPrefix(model, "gemini-") {
-		return exec.CommandContext(ctx, "gemini", "--model", model, "-p", prompt)
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
 	}
-	return exec.CommandContext(ctx, "claude", "--dangerously-skip-permissions", "-p", prompt)
+	return s[:maxLen] + "\n...[truncated]"
 }
 
-func truncate(s string, maxLen int) string {
+// tailString keeps the last maxLen bytes of s, where test runners print their verdict.
+func tailString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen] + "\n...[truncated]"
+	return "...[truncated]\n" + s[len(s)-maxLen:]
+}
+
+// LogName returns the base name, without extension, of the reviewer log for
+// the task slug at iteration. With consensus review each reviewer's log adds
+// "-<model>" to it.
+func LogName(slug string, iteration int) string {
+	return fmt.Sprintf("%s-reviewer-iter%d", slug, iteration)
 }
 
 func slugify(s string) string {
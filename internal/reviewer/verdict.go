@@ -0,0 +1,138 @@
+package reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatJSON selects the structured verdict schema for Options.Format.
+const FormatJSON = "json"
+
+// DefaultRubric lists the criteria a structured verdict scores when
+// Options.Rubric is empty.
+var DefaultRubric = []string{"correctness", "completeness", "tests", "code quality"}
+
+// Verdict is a structured reviewer verdict.
+type Verdict struct {
+	Done     bool           `json:"done"`
+	Summary  string         `json:"summary,omitempty"`
+	Scores   map[string]int `json:"scores,omitempty"` // rubric criterion → 1..5
+	Blocking []string       `json:"blocking_issues,omitempty"`
+	Comments []Comment      `json:"comments,omitempty"`
+}
+
+// Comment is a reviewer note attached to a file and, optionally, a line.
+type Comment struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	Body string `json:"comment"`
+}
+
+const verdictSchemaTmpl = `Respond with a single JSON object and nothing else, in this shape:
+{
+  "done": true | false,
+  "summary": "<one or two sentences>",
+  "scores": { {{- range $i, $c := .}}{{if $i}}, {{end}}"{{$c}}": 1-5{{end}} },
+  "blocking_issues": ["<issue that must be fixed before this can merge>"],
+  "comments": [{"file": "<path>", "line": <line number or 0>, "comment": "<note>"}]
+}
+
+Rules:
+- Set "done" to true only if the task is fully and correctly completed.
+- Anything listed in "blocking_issues" sends the work back to the worker, so
+  only list problems that must be fixed.
+- Score each criterion from 1 (poor) to 5 (excellent).
+- Be specific so the worker can address every issue.`
+
+// parseVerdict extracts a Verdict from the reviewer's output. It accepts a
+// bare JSON object or one inside a ```json fence, surrounded by other text.
+func parseVerdict(output string) (Verdict, bool) {
+	candidate := output
+	if start := strings.Index(candidate, "```json"); start >= 0 {
+		candidate = candidate[start+len("```json"):]
+		if end := strings.Index(candidate, "```"); end >= 0 {
+			candidate = candidate[:end]
+		}
+	}
+	start := strings.Index(candidate, "{")
+	end := strings.LastIndex(candidate, "}")
+	if start < 0 || end < start {
+		return Verdict{}, false
+	}
+
+	var raw struct {
+		Verdict
+		Done *bool `json:"done"`
+	}
+	if err := json.Unmarshal([]byte(candidate[start:end+1]), &raw); err != nil || raw.Done == nil {
+		return Verdict{}, false
+	}
+	v := raw.Verdict
+	v.Done = *raw.Done
+	return v, true
+}
+
+// decisionFromVerdict turns a structured verdict into a Decision. Blocking
+// issues veto a "done" verdict.
+func decisionFromVerdict(v Verdict, raw string) Decision {
+	return Decision{
+		Done:     v.Done && len(v.Blocking) == 0,
+		Feedback: v.Markdown(),
+		Raw:      raw,
+		Verdict:  &v,
+	}
+}
+
+// Markdown renders the verdict for FEEDBACK.md and pull request bodies.
+func (v Verdict) Markdown() string {
+	var b strings.Builder
+	if v.Summary != "" {
+		fmt.Fprintf(&b, "**Summary:** %s\n\n", v.Summary)
+	}
+	if len(v.Scores) > 0 {
+		criteria := make([]string, 0, len(v.Scores))
+		for c := range v.Scores {
+			criteria = append(criteria, c)
+		}
+		sort.Strings(criteria)
+		b.WriteString("| Criterion | Score |\n|---|---|\n")
+		for _, c := range criteria {
+			fmt.Fprintf(&b, "| %s | %d/5 |\n", c, v.Scores[c])
+		}
+		b.WriteString("\n")
+	}
+	if len(v.Blocking) > 0 {
+		b.WriteString("**Blocking issues:**\n\n")
+		for _, issue := range v.Blocking {
+			fmt.Fprintf(&b, "- %s\n", issue)
+		}
+		b.WriteString("\n")
+	}
+	if len(v.Comments) > 0 {
+		b.WriteString("**Comments:**\n\n")
+		for _, c := range v.Comments {
+			loc := c.File
+			if c.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", c.File, c.Line)
+			}
+			fmt.Fprintf(&b, "- `%s` — %s\n", loc, c.Body)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// StoredMarkdown renders a verdict stored as JSON, as memory keeps the last
+// review, or returns "" when stored holds none (a plain DONE/RETRY review).
+func StoredMarkdown(stored string) string {
+	if stored == "" {
+		return ""
+	}
+	var v Verdict
+	if err := json.Unmarshal([]byte(stored), &v); err != nil {
+		return ""
+	}
+	return v.Markdown()
+}
@@ -0,0 +1,105 @@
+package reviewer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVerdict_Bare(t *testing.T) {
+	out := `{"done": true, "summary": "Looks good", "scores": {"correctness": 5, "tests": 4}}`
+	v, ok := parseVerdict(out)
+	if !ok {
+		t.Fatal("expected verdict to parse")
+	}
+	if !v.Done || v.Summary != "Looks good" || v.Scores["tests"] != 4 {
+		t.Errorf("verdict = %+v", v)
+	}
+}
+
+func TestParseVerdict_FencedWithProse(t *testing.T) {
+	out := "Here is my review.\n\n```json\n" + `{
+  "done": false,
+  "blocking_issues": ["nav does not close on Escape"],
+  "comments": [{"file": "nav.go", "line": 12, "comment": "missing key handler"}]
+}` + "\n```\nThanks!"
+	v, ok := parseVerdict(out)
+	if !ok {
+		t.Fatal("expected fenced verdict to parse")
+	}
+	if v.Done || len(v.Blocking) != 1 || len(v.Comments) != 1 || v.Comments[0].Line != 12 {
+		t.Errorf("verdict = %+v", v)
+	}
+}
+
+func TestParseVerdict_RequiresDone(t *testing.T) {
+	for _, out := range []string{
+		"DONE",
+		`{"summary": "no done flag"}`,
+		`{"done": tru`,
+	} {
+		if _, ok := parseVerdict(out); ok {
+			t.Errorf("parseVerdict(%q) should fail", out)
+		}
+	}
+}
+
+func TestDecisionFromVerdict_BlockingVetoesDone(t *testing.T) {
+	d := decisionFromVerdict(Verdict{Done: true, Blocking: []string{"tests fail"}}, "")
+	if d.Done {
+		t.Error("blocking issues should keep the task open")
+	}
+	if d.Verdict == nil {
+		t.Error("decision should carry the verdict")
+	}
+	if !strings.Contains(d.Feedback, "- tests fail") {
+		t.Errorf("feedback missing blocking issue: %q", d.Feedback)
+	}
+}
+
+func TestVerdictMarkdown(t *testing.T) {
+	v := Verdict{
+		Summary:  "Mostly there",
+		Scores:   map[string]int{"tests": 2, "correctness": 4},
+		Blocking: []string{"no tests for Close"},
+		Comments: []Comment{{File: "nav.go", Line: 3, Body: "rename"}, {File: "README.md", Body: "document it"}},
+	}
+	md := v.Markdown()
+	for _, want := range []string{
+		"**Summary:** Mostly there",
+		"| correctness | 4/5 |\n| tests | 2/5 |",
+		"- no tests for Close",
+		"- `nav.go:3` — rename",
+		"- `README.md` — document it",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestStoredMarkdown(t *testing.T) {
+	tests := []struct{ stored, want string }{
+		{"", ""},
+		{"RETRY: add tests", ""},
+		{`{"done": true, "summary": "Looks good"}`, "**Summary:** Looks good"},
+	}
+	for _, tt := range tests {
+		if got := StoredMarkdown(tt.stored); got != tt.want {
+			t.Errorf("StoredMarkdown(%q) = %q; want %q", tt.stored, got, tt.want)
+		}
+	}
+}
+
+func TestBuildReviewPrompt_JSONFormat(t *testing.T) {
+	opts := Options{Task: "Add nav", WorkerOutput: "done", Iteration: 1, MaxIter: 1, Format: FormatJSON, Rubric: []string{"correctness", "docs"}}
+	prompt, err := buildReviewPrompt(opts, Changes{}, nil)
+	if err != nil {
+		t.Fatalf("buildReviewPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, `"scores": {"correctness": 1-5, "docs": 1-5 }`) {
+		t.Errorf("prompt missing rubric schema:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "RETRY:") {
+		t.Errorf("json prompt should not ask for a RETRY line:\n%s", prompt)
+	}
+}
@@ -0,0 +1,322 @@
+// Package setup prepares a new task worktree before its agent runs: it seeds
+// untracked files from the main checkout and runs setup commands, restoring
+// cached command outputs when an earlier worktree already produced them.
+package setup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+// CacheDir holds cached setup outputs, relative to the repo root.
+const CacheDir = ".mochi/cache/setup"
+
+// Step is one setup command. When Outputs is set, the listed paths are cached
+// after the command succeeds, keyed by the command and the contents of the
+// files matching Key (e.g. package-lock.json); a later worktree with the same
+// key gets a copy of them instead of running the command.
+type Step struct {
+	Run     string
+	Outputs []string
+	Key     []string
+}
+
+// Preparer seeds and sets up worktrees. It is safe for concurrent use: tasks
+// that need the same cache entry wait for the first one to fill it.
+type Preparer struct {
+	RepoRoot string
+	Copy     []string // paths or globs in the main checkout copied into each worktree
+	Link     []string // paths or globs in the main checkout symlinked into each worktree
+	Steps    []Step
+	Timeout  time.Duration // per command; zero means no limit
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Enabled reports whether there is anything to do.
+func (p *Preparer) Enabled() bool {
+	return p != nil && len(p.Copy)+len(p.Link)+len(p.Steps) > 0
+}
+
+// Prepare seeds the worktree at dir and runs the setup steps in it, in order,
+// writing a record of each step to w. It stops at the first failure.
+func (p *Preparer) Prepare(ctx context.Context, dir string, w io.Writer) error {
+	copied, err := p.seed(dir, p.Copy, copyPath, "copied", w)
+	if err != nil {
+		return err
+	}
+	linked, err := p.seed(dir, p.Link, linkPath, "linked", w)
+	if err != nil {
+		return err
+	}
+	if err := p.exclude(dir, append(copied, linked...)); err != nil {
+		return fmt.Errorf("setup: cannot exclude seeded paths from git: %w", err)
+	}
+	for _, step := range p.Steps {
+		if err := p.runStep(ctx, dir, step, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seed applies fn to every main-checkout path matching patterns and returns
+// the seeded paths, relative to the worktree.
+func (p *Preparer) seed(dir string, patterns []string, fn func(src, dst string) error, verb string, w io.Writer) ([]string, error) {
+	var seeded []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(p.RepoRoot, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("setup: invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("setup: %q matches nothing in %s", pattern, p.RepoRoot)
+		}
+		for _, src := range matches {
+			rel, err := filepath.Rel(p.RepoRoot, src)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("setup: %q is outside the repository", pattern)
+			}
+			if err := fn(src, filepath.Join(dir, rel)); err != nil {
+				return nil, fmt.Errorf("setup: cannot seed %s: %w", rel, err)
+			}
+			fmt.Fprintf(w, "[SETUP] %s %s\n", verb, rel)
+			seeded = append(seeded, rel)
+		}
+	}
+	return seeded, nil
+}
+
+// exclude adds the seeded paths to the repository's info/exclude, so the
+// agent's "commit all changes" never commits them. A symlinked directory is
+// not matched by a "node_modules/" gitignore pattern, and a seeded file need
+// not be ignored at all. The file is shared by every worktree; the paths are
+// anchored at the root and were untracked in the main checkout already.
+func (p *Preparer) exclude(dir string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--git-path", "info/exclude").Output()
+	if err != nil {
+		return nil // not a git worktree: nothing can be committed
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	var add strings.Builder
+	for _, rel := range paths {
+		if pattern := "/" + filepath.ToSlash(rel); !existing[pattern] {
+			existing[pattern] = true
+			add.WriteString(pattern + "\n")
+		}
+	}
+	if add.Len() == 0 {
+		return nil
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, add.String()...), 0644)
+}
+
+func (p *Preparer) runStep(ctx context.Context, dir string, step Step, w io.Writer) error {
+	if len(step.Outputs) == 0 {
+		return run(ctx, dir, step.Run, p.Timeout, w)
+	}
+
+	key, err := cacheKey(dir, step)
+	if err != nil {
+		return err
+	}
+	lock := p.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	cache := filepath.Join(p.RepoRoot, CacheDir, key)
+	if _, err := os.Stat(cache); err == nil {
+		for _, out := range step.Outputs {
+			if err := copyPath(filepath.Join(cache, out), filepath.Join(dir, out)); err != nil {
+				return fmt.Errorf("setup: cannot restore %s from cache: %w", out, err)
+			}
+		}
+		fmt.Fprintf(w, "[SETUP] %s | cached (%s)\n", step.Run, key[:12])
+		return nil
+	}
+
+	if err := run(ctx, dir, step.Run, p.Timeout, w); err != nil {
+		return err
+	}
+	if err := store(dir, cache, step.Outputs); err != nil {
+		// The worktree is set up; only later worktrees lose the shortcut.
+		fmt.Fprintf(w, "[SETUP] not cached: %v\n", err)
+	}
+	return nil
+}
+
+func (p *Preparer) lock(key string) *sync.Mutex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.locks == nil {
+		p.locks = make(map[string]*sync.Mutex)
+	}
+	if p.locks[key] == nil {
+		p.locks[key] = &sync.Mutex{}
+	}
+	return p.locks[key]
+}
+
+func run(ctx context.Context, dir, command string, timeout time.Duration, w io.Writer) error {
+	r := verify.Run(ctx, dir, []string{command}, timeout)[0]
+	status := "passed"
+	if !r.Passed {
+		status = "FAILED"
+	}
+	fmt.Fprintf(w, "[SETUP] %s | %s | duration=%.0fs\n", command, status, r.Duration.Seconds())
+	if out := strings.TrimSpace(r.Output); out != "" {
+		fmt.Fprintln(w, out)
+	}
+	if !r.Passed {
+		return fmt.Errorf("setup command failed: %s\n%s", command, strings.TrimSpace(r.Output))
+	}
+	return nil
+}
+
+// cacheKey hashes the step's command, outputs and the files matching its Key
+// patterns in dir.
+func cacheKey(dir string, step Step) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", step.Run, strings.Join(step.Outputs, "\x00"))
+	var files []string
+	for _, pattern := range step.Key {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", fmt.Errorf("setup: invalid key pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("setup: cannot read key file: %w", err)
+		}
+		rel, _ := filepath.Rel(dir, f)
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// store copies the outputs from dir into the cache entry at cache. The entry
+// is assembled next to its final location and renamed into place, so readers
+// never see a partial entry.
+func store(dir, cache string, outputs []string) error {
+	if err := os.MkdirAll(filepath.Dir(cache), 0755); err != nil {
+		return err
+	}
+	// Keep the cache out of the repository's git status.
+	if err := os.WriteFile(filepath.Join(filepath.Dir(cache), ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(cache), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, out := range outputs {
+		if err := copyPath(filepath.Join(dir, out), filepath.Join(tmp, out)); err != nil {
+			return fmt.Errorf("cannot copy %s: %w", out, err)
+		}
+	}
+	return os.Rename(tmp, cache)
+}
+
+// copyPath copies the file, directory tree or symlink at src to dst,
+// replacing whatever is at dst.
+func copyPath(src, dst string) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// linkPath symlinks dst to src, replacing whatever is at dst.
+func linkPath(src, dst string) error {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(src, dst)
+}
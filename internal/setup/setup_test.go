@@ -0,0 +1,125 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepare_SeedsFiles(t *testing.T) {
+	repo, dir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(repo, ".env"), []byte("TOKEN=1\n"), 0600)
+	os.WriteFile(filepath.Join(repo, ".env.local"), []byte("DEBUG=1\n"), 0600)
+	os.MkdirAll(filepath.Join(repo, "gen", "api"), 0755)
+	os.WriteFile(filepath.Join(repo, "gen", "api", "client.go"), []byte("package api\n"), 0644)
+
+	p := &Preparer{RepoRoot: repo, Copy: []string{".env*"}, Link: []string{"gen"}}
+	var log bytes.Buffer
+	if err := p.Prepare(context.Background(), dir, &log); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, ".env.local")); string(data) != "DEBUG=1\n" {
+		t.Errorf(".env.local = %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(dir, ".env")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf(".env not copied with its mode: %v %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "gen")); err != nil || target != filepath.Join(repo, "gen") {
+		t.Errorf("gen link = %q, %v", target, err)
+	}
+	if !strings.Contains(log.String(), "[SETUP] linked gen") {
+		t.Errorf("log = %q", log.String())
+	}
+
+	p = &Preparer{RepoRoot: repo, Copy: []string{"missing.txt"}}
+	if err := p.Prepare(context.Background(), dir, &log); err == nil {
+		t.Error("expected an error for a path that matches nothing")
+	}
+}
+
+func TestPrepare_SeededPathsStayUntracked(t *testing.T) {
+	repo := t.TempDir()
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	git(repo, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("node_modules/\n"), 0644)
+	git(repo, "add", ".")
+	git(repo, "-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-qm", "init")
+	os.MkdirAll(filepath.Join(repo, "node_modules", "left-pad"), 0755)
+	os.WriteFile(filepath.Join(repo, "node_modules", "left-pad", "index.js"), []byte("\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "local.env"), []byte("TOKEN=1\n"), 0600)
+
+	dir := filepath.Join(t.TempDir(), "wt")
+	git(repo, "worktree", "add", "-q", dir)
+	p := &Preparer{RepoRoot: repo, Copy: []string{"local.env"}, Link: []string{"node_modules"}}
+	for i := 0; i < 2; i++ { // seeding again adds nothing twice
+		if err := p.Prepare(context.Background(), dir, io.Discard); err != nil {
+			t.Fatalf("Prepare failed: %v", err)
+		}
+	}
+
+	if status := git(dir, "status", "--porcelain"); status != "" {
+		t.Errorf("seeded paths show up in git status:\n%s", status)
+	}
+	exclude, _ := os.ReadFile(filepath.Join(repo, ".git", "info", "exclude"))
+	if n := strings.Count(string(exclude), "/node_modules\n"); n != 1 {
+		t.Errorf("info/exclude lists /node_modules %d times:\n%s", n, exclude)
+	}
+}
+
+func TestPrepare_CachesOutputs(t *testing.T) {
+	repo := t.TempDir()
+	counter := filepath.Join(repo, "runs")
+	p := &Preparer{RepoRoot: repo, Steps: []Step{{
+		Run:     "echo run >> " + counter + " && mkdir -p deps && cat lock > deps/installed",
+		Outputs: []string{"deps"},
+		Key:     []string{"lock"},
+	}}}
+
+	prepare := func(lock string) string {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "lock"), []byte(lock), 0644)
+		if err := p.Prepare(context.Background(), dir, &bytes.Buffer{}); err != nil {
+			t.Fatalf("Prepare failed: %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, "deps", "installed"))
+		return string(data)
+	}
+
+	if got := prepare("v1"); got != "v1" {
+		t.Errorf("first worktree got %q", got)
+	}
+	if got := prepare("v1"); got != "v1" {
+		t.Errorf("cached worktree got %q", got)
+	}
+	if got := prepare("v2"); got != "v2" {
+		t.Errorf("worktree with a new key got %q", got)
+	}
+	if data, _ := os.ReadFile(counter); strings.Count(string(data), "run") != 2 {
+		t.Errorf("command ran %d times; want 2 (one per key)", strings.Count(string(data), "run"))
+	}
+}
+
+func TestPrepare_CommandFailure(t *testing.T) {
+	p := &Preparer{RepoRoot: t.TempDir(), Steps: []Step{{Run: "echo broken install && exit 1"}, {Run: "touch never"}}}
+	dir := t.TempDir()
+	err := p.Prepare(context.Background(), dir, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "broken install") {
+		t.Fatalf("err = %v; want the command output", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "never")); statErr == nil {
+		t.Error("setup continued after a failing command")
+	}
+}
@@ -0,0 +1,374 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// TaskState is a snapshot of one task as shown on the dashboard.
+type TaskState struct {
+	Slug          string
+	Model         string
+	Status        string // pending | running | done | failed | skipped | cancelled
+	Iteration     int
+	MaxIterations int
+	Started       time.Time
+	Finished      time.Time
+	LogPath       string
+}
+
+// Controller lets the dashboard act on the tasks it displays. Its methods are
+// called from the UI loop and must not block on Dashboard.Update.
+type Controller interface {
+	Cancel(slug string)
+	Rerun(slug string)
+	CancelAll()
+}
+
+// Dashboard is a running full-screen task dashboard.
+type Dashboard struct {
+	program *tea.Program
+	done    chan struct{}
+}
+
+type taskUpdateMsg TaskState
+
+type dashboardTickMsg struct{}
+
+// dashboardTailLines is how many log lines the detail pane shows.
+const dashboardTailLines = 8
+
+// DashboardSupported reports whether stdout is a TTY the dashboard can draw on.
+func DashboardSupported() bool {
+	return term.IsTerminal(os.Stdout.Fd())
+}
+
+// StartDashboard launches the dashboard in the alternate screen and returns
+// immediately. Feed it state changes with Update and block on Wait until the
+// user leaves it; leaving is only possible once no task is running.
+func StartDashboard(tasks []TaskState, ctrl Controller) *Dashboard {
+	m := dashboardModel{
+		tasks: append([]TaskState(nil), tasks...),
+		ctrl:  ctrl,
+		tails: make(map[string][]string),
+	}
+	d := &Dashboard{
+		program: tea.NewProgram(m, tea.WithAltScreen()),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(d.done)
+		_, _ = d.program.Run()
+	}()
+	return d
+}
+
+// Update replaces the displayed state of the task with the same slug.
+// It is safe to call from any goroutine.
+func (d *Dashboard) Update(state TaskState) {
+	d.program.Send(taskUpdateMsg(state))
+}
+
+// Close shuts the dashboard down without waiting for the user.
+func (d *Dashboard) Close() {
+	d.program.Quit()
+}
+
+// Wait blocks until the user leaves the dashboard.
+func (d *Dashboard) Wait() {
+	<-d.done
+}
+
+type dashboardModel struct {
+	tasks  []TaskState
+	ctrl   Controller
+	cursor int
+	tails  map[string][]string
+	width  int
+	height int
+
+	// full-log view
+	viewing  bool
+	viewport viewport.Model
+
+	notice string
+}
+
+func (m dashboardModel) Init() tea.Cmd { return dashboardTick() }
+
+func dashboardTick() tea.Cmd {
+	return tea.Tick(500*time.Millisecond, func(time.Time) tea.Msg { return dashboardTickMsg{} })
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-3, 1)
+		return m, nil
+
+	case taskUpdateMsg:
+		for i := range m.tasks {
+			if m.tasks[i].Slug == msg.Slug {
+				m.tasks[i] = TaskState(msg)
+			}
+		}
+		return m, nil
+
+	case dashboardTickMsg:
+		for _, t := range m.tasks {
+			m.tails[t.Slug] = tailFile(t.LogPath, dashboardTailLines)
+		}
+		if m.viewing {
+			m.refreshLogView()
+		}
+		return m, dashboardTick()
+
+	case tea.KeyMsg:
+		if m.viewing {
+			return m.updateLogView(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+func (m dashboardModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.notice = ""
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.tasks)-1 {
+			m.cursor++
+		}
+	case "enter", "l":
+		if len(m.tasks) > 0 {
+			m.viewing = true
+			m.viewport = viewport.New(m.width, max(m.height-3, 1))
+			m.refreshLogView()
+			m.viewport.GotoBottom()
+		}
+	case "c", "x":
+		if t, ok := m.selected(); ok {
+			if t.Status == "running" || t.Status == "pending" {
+				m.ctrl.Cancel(t.Slug)
+				m.notice = "cancelling " + t.Slug + "…"
+			} else {
+				m.notice = t.Slug + " is not running"
+			}
+		}
+	case "r":
+		if t, ok := m.selected(); ok {
+			if t.Status == "running" || t.Status == "pending" {
+				m.notice = t.Slug + " is still running"
+			} else {
+				// Show it as pending right away so q cannot quit before the
+				// orchestrator reports the re-run.
+				m.tasks[m.cursor].Status = "pending"
+				m.ctrl.Rerun(t.Slug)
+				m.notice = "re-running " + t.Slug + "…"
+			}
+		}
+	case "q", "esc":
+		if m.anyActive() {
+			m.notice = "tasks are still running — cancel them or press ctrl+c to cancel all"
+			return m, nil
+		}
+		return m, tea.Quit
+	case "ctrl+c":
+		m.ctrl.CancelAll()
+		m.notice = "cancelling all tasks…"
+		if !m.anyActive() {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m dashboardModel) updateLogView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "h":
+		m.viewing = false
+		return m, nil
+	case "ctrl+c":
+		m.viewing = false
+		return m.updateList(msg)
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *dashboardModel) refreshLogView() {
+	t, ok := m.selected()
+	if !ok {
+		return
+	}
+	atBottom := m.viewport.AtBottom()
+	data, err := os.ReadFile(t.LogPath)
+	if err != nil {
+		m.viewport.SetContent(fmt.Sprintf("(no log yet at %s)", t.LogPath))
+		return
+	}
+	m.viewport.SetContent(string(data))
+	if atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
+func (m dashboardModel) selected() (TaskState, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.tasks) {
+		return TaskState{}, false
+	}
+	return m.tasks[m.cursor], true
+}
+
+func (m dashboardModel) anyActive() bool {
+	for _, t := range m.tasks {
+		if t.Status == "running" || t.Status == "pending" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m dashboardModel) View() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF"))
+	mutedStyle := lipgloss.NewStyle().Foreground(ColorMuted)
+
+	if m.viewing {
+		t, _ := m.selected()
+		header := titleStyle.Render(t.Slug) + mutedStyle.Render("  "+t.LogPath)
+		footer := mutedStyle.Render(fmt.Sprintf("%3.0f%%  ↑/↓ scroll · esc back", m.viewport.ScrollPercent()*100))
+		return lipgloss.JoinVertical(lipgloss.Left, header, m.viewport.View(), footer)
+	}
+
+	var b strings.Builder
+	b.WriteString(BannerStyle.Render("MOCHI") + " " + titleStyle.Render("Task dashboard") + "\n\n")
+
+	header := fmt.Sprintf("  %-2s %-32s %-20s %-7s %-8s %s", "", "TASK", "MODEL", "ITER", "ELAPSED", "LAST LOG LINE")
+	b.WriteString(mutedStyle.Render(header) + "\n")
+
+	lastLineWidth := max(m.width-78, 10)
+	for i, t := range m.tasks {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = lipgloss.NewStyle().Foreground(ColorPrimary).Bold(true).Render("▸ ")
+		}
+		iter := "-"
+		if t.Iteration > 0 {
+			iter = fmt.Sprintf("%d/%d", t.Iteration, max(t.MaxIterations, 1))
+		}
+		last := ""
+		if tail := m.tails[t.Slug]; len(tail) > 0 {
+			last = tail[len(tail)-1]
+		}
+		row := fmt.Sprintf("%s %-32s %-20s %-7s %-8s %s",
+			statusIcon(t.Status),
+			truncateRunes(t.Slug, 32),
+			truncateRunes(t.Model, 20),
+			iter,
+			elapsed(t),
+			mutedStyle.Render(truncateRunes(last, lastLineWidth)),
+		)
+		b.WriteString(cursor + row + "\n")
+	}
+
+	if t, ok := m.selected(); ok {
+		b.WriteString("\n" + titleStyle.Render(t.Slug) + mutedStyle.Render(" — "+t.Status) + "\n")
+		tail := m.tails[t.Slug]
+		if len(tail) == 0 {
+			b.WriteString(mutedStyle.Render("  (no log output yet)") + "\n")
+		}
+		for _, line := range tail {
+			b.WriteString(mutedStyle.Render("  "+truncateRunes(line, max(m.width-4, 20))) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if m.notice != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorAccent).Render(m.notice) + "\n")
+	}
+	help := "↑/↓ select · enter full log · c cancel · r re-run · ctrl+c cancel all"
+	if !m.anyActive() {
+		help += " · q continue"
+	}
+	b.WriteString(mutedStyle.Render(help))
+	return b.String()
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case "running":
+		return lipgloss.NewStyle().Foreground(ColorAccent).Render("⟳")
+	case "done":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B")).Render("✓")
+	case "failed":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("✗")
+	case "cancelled", "skipped":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C")).Render("⊘")
+	default:
+		return lipgloss.NewStyle().Foreground(ColorMuted).Render("·")
+	}
+}
+
+func elapsed(t TaskState) string {
+	if t.Started.IsZero() {
+		return "-"
+	}
+	end := t.Finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(t.Started).Truncate(time.Second).String()
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// tailFile returns the last n non-empty lines of the file at path.
+func tailFile(path string, n int) []string {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
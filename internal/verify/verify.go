@@ -0,0 +1,136 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/proc"
+)
+
+// maxOutput bounds the output kept per command; the tail is kept because test
+// runners and linters print their verdict last.
+const maxOutput = 4000
+
+// Result is the outcome of one verification command.
+type Result struct {
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	Output   string        `json:"output,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Run executes each command through the shell inside dir, in order. Every
+// command runs even if an earlier one fails so the agent sees all failures at
+// once. A timeout of zero means no per-command limit.
+func Run(ctx context.Context, dir string, commands []string, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(commands))
+	for _, command := range commands {
+		results = append(results, runOne(ctx, dir, command, timeout))
+	}
+	return results
+}
+
+func runOne(ctx context.Context, dir, command string, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	proc.KillGroupOnCancel(cmd, proc.Grace)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	output := out.String()
+	if ctx.Err() == context.DeadlineExceeded {
+		output += fmt.Sprintf("\n[timed out after %s]", timeout)
+	} else if err != nil && out.Len() == 0 {
+		output = err.Error()
+	}
+
+	return Result{
+		Command:  command,
+		Passed:   err == nil,
+		Output:   tail(output, maxOutput),
+		Duration: time.Since(start),
+	}
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// Passed reports whether every result passed. An empty slice passes.
+func Passed(results []Result) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the commands that did not pass.
+func Failed(results []Result) []string {
+	var failed []string
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, r.Command)
+		}
+	}
+	return failed
+}
+
+// Summary renders the failing results as Markdown for the agent's feedback.
+// It returns "" when everything passed.
+func Summary(results []Result) string {
+	if Passed(results) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("The following verification commands failed. Fix the code so they pass.\n")
+	for _, r := range results {
+		if r.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### `%s`\n\n```\n%s\n```\n", r.Command, strings.TrimSpace(r.Output))
+	}
+	return b.String()
+}
+
+// WriteLog appends a human-readable record of results to w (usually the
+// iteration's agent log).
+func WriteLog(w io.Writer, results []Result) {
+	for _, r := range results {
+		status := "passed"
+		if !r.Passed {
+			status = "FAILED"
+		}
+		fmt.Fprintf(w, "[VERIFY] %s | %s | duration=%.0fs\n", r.Command, status, r.Duration.Seconds())
+		if out := strings.TrimSpace(r.Output); out != "" {
+			fmt.Fprintln(w, out)
+		}
+	}
+}
+
+func tail(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return "...[truncated]\n" + s[len(s)-maxLen:]
+}
@@ -0,0 +1,57 @@
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_PassAndFail(t *testing.T) {
+	dir := t.TempDir()
+	results := Run(context.Background(), dir, []string{
+		"echo ok",
+		"echo 'lint: unused variable' && exit 3",
+		"pwd",
+	}, 0)
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results; want 3 (all commands run)", len(results))
+	}
+	if !results[0].Passed || results[1].Passed || !results[2].Passed {
+		t.Errorf("passed = %v, %v, %v; want true, false, true", results[0].Passed, results[1].Passed, results[2].Passed)
+	}
+	if !strings.Contains(results[2].Output, dir) {
+		t.Errorf("command did not run in %s: %q", dir, results[2].Output)
+	}
+	if Passed(results) {
+		t.Error("Passed = true; want false")
+	}
+	if got := Failed(results); len(got) != 1 || got[0] != results[1].Command {
+		t.Errorf("Failed = %v; want [%s]", got, results[1].Command)
+	}
+
+	summary := Summary(results)
+	if !strings.Contains(summary, "lint: unused variable") || strings.Contains(summary, "echo ok") {
+		t.Errorf("Summary should list only failing commands with output, got:\n%s", summary)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	results := Run(context.Background(), t.TempDir(), []string{"sleep 5"}, 100*time.Millisecond)
+	if results[0].Passed {
+		t.Fatal("expected timed-out command to fail")
+	}
+	if !strings.Contains(results[0].Output, "timed out") {
+		t.Errorf("Output = %q; want timeout note", results[0].Output)
+	}
+}
+
+func TestSummary_AllPassed(t *testing.T) {
+	if got := Summary([]Result{{Command: "true", Passed: true}}); got != "" {
+		t.Errorf("Summary = %q; want empty", got)
+	}
+	if !Passed(nil) {
+		t.Error("Passed(nil) = false; want true")
+	}
+}
@@ -1,32 +1,113 @@
 package worktree
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/thisguymartin/ai-forge/internal/parser"
 )
 
-const manifestFile = ".mochi_manifest.json"
+// manifestFile lives at the repo root. Every read-modify-write of it holds an
+// OS lock on lockFileName in the git common dir, so concurrent mochi processes
+// in the same repo do not clobber each other's entries and the lock never shows
+// up as an untracked file.
+const (
+	manifestFile = ".mochi_manifest.json"
+	lockFileName = "mochi_manifest.lock"
+)
 
 // Entry tracks a single git worktree created by MOCHI.
 type Entry struct {
 	Slug   string `json:"slug"`
 	Path   string `json:"path"`
 	Branch string `json:"branch"`
-	Status string `json:"status"` // pending | running | done | failed
+	Status string `json:"status"`         // pending | running | done | failed | skipped | cancelled
+	Base   string `json:"base,omitempty"` // commit the task's own work starts from
+
+	RunID   string    `json:"run_id,omitempty"` // run that created the worktree
+	Created time.Time `json:"created,omitempty"`
+
+	// Resume state: the task this worktree runs, whether its setup
+	// completed, the last Ralph Loop iteration started, and the PR opened for
+	// it (if any).
+	Task      *parser.Task `json:"task,omitempty"`
+	Prepared  bool         `json:"prepared,omitempty"`
+	Iteration int          `json:"iteration,omitempty"`
+	PRURL     string       `json:"pr_url,omitempty"`
 }
 
 // Manager creates and destroys git worktrees for each task.
+//
+// Entries are namespaced by RunID: a manager only sees, updates and removes
+// entries of its own run, so two runs may use the same task slug. With an
+// empty RunID it works on entries written before runs had IDs.
 type Manager struct {
 	BaseBranch   string
 	BranchPrefix string
 	WorktreeDir  string
 	RepoRoot     string
+	RunID        string
 	mu           sync.Mutex
+	lock         string // manifest lock path, resolved on first use
+}
+
+// NewRunID returns an ID for a new run: its start time plus a random suffix,
+// so IDs sort by age and concurrent runs never share one.
+func NewRunID() string {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// LatestRun returns the ID of the most recently created run among entries,
+// or "" when none has an ID.
+func LatestRun(entries []*Entry) string {
+	var latest *Entry
+	for _, e := range entries {
+		if e.RunID != "" && (latest == nil || e.Created.After(latest.Created)) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.RunID
+}
+
+// FindEntry returns the entry for slug in run or, when run is empty, in the
+// most recent run that has one. It returns nil when there is none.
+func FindEntry(entries []*Entry, slug, run string) *Entry {
+	var found *Entry
+	for _, e := range entries {
+		if e.Slug != slug || (run != "" && e.RunID != run) {
+			continue
+		}
+		if found == nil || e.Created.After(found.Created) {
+			found = e
+		}
+	}
+	return found
+}
+
+// RunEntries returns the entries that belong to run.
+func RunEntries(entries []*Entry, run string) []*Entry {
+	var matched []*Entry
+	for _, e := range entries {
+		if e.RunID == run {
+			matched = append(matched, e)
+		}
+	}
+	return matched
 }
 
 // NewManager returns a Manager rooted at repoRoot.
@@ -40,31 +121,63 @@ func NewManager(repoRoot, baseBranch, branchPrefix, worktreeDir string) *Manager
 }
 
 // Create spins up a new git worktree for the given slug. If the branch name
-// already exists it appends a numeric suffix to avoid collision.
+// already exists it appends a numeric suffix to avoid collision. When another
+// run's entry holds the slug's worktree path, the run ID is appended to the
+// directory name.
 func (m *Manager) Create(slug string) (*Entry, error) {
 	if err := m.ensureBaseRefExists(); err != nil {
 		return nil, err
 	}
 
+	var entry *Entry
+	err := m.update(func(manifest map[string]Entry) error {
+		var err error
+		entry, err = m.create(manifest, slug)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// create runs under the manifest lock so concurrent runs resolve paths and
+// branch names one at a time.
+func (m *Manager) create(manifest map[string]Entry, slug string) (*Entry, error) {
 	path, _ := filepath.Abs(filepath.Join(m.WorktreeDir, slug))
+	if m.RunID != "" && heldByOtherRun(manifest, path, m.key(slug)) {
+		path += "-" + m.RunID
+	}
+
+	entry := &Entry{
+		Slug:    slug,
+		Path:    path,
+		Status:  "pending",
+		RunID:   m.RunID,
+		Created: time.Now(),
+	}
 
 	// 1. If it's already a worktree, reuse it
 	if isWorktree(m.RepoRoot, path) {
-		branch := getWorktreeBranch(m.RepoRoot, path)
-		if branch != "" {
-			entry := &Entry{
-				Slug:   slug,
-				Path:   path,
-				Branch: branch,
-				Status: "pending",
-			}
-			if err := m.saveEntry(entry); err != nil {
-				return nil, err
+		if branch := getWorktreeBranch(m.RepoRoot, path); branch != "" {
+			base, err := gitOutput(path, "merge-base", m.BaseBranch, "HEAD")
+			if err != nil {
+				return nil, fmt.Errorf("cannot find where %q forked from %q: %w", branch, m.BaseBranch, err)
 			}
+			entry.Branch, entry.Base = branch, base
+			manifest[m.key(slug)] = *entry
 			return entry, nil
 		}
 	}
 
+	// Record the base as a commit: diffs against a branch name would pick up
+	// whatever lands on it during the run.
+	base, err := gitOutput(m.RepoRoot, "rev-parse", "--verify", m.BaseBranch+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve base branch %q: %w", m.BaseBranch, err)
+	}
+	entry.Base = base
+
 	// 2. If directory exists but not a worktree, remove it
 	if _, err := os.Stat(path); err == nil {
 		if err := os.RemoveAll(path); err != nil {
@@ -77,31 +190,97 @@ func (m *Manager) Create(slug string) (*Entry, error) {
 	}
 
 	// 3. Decide branch name. If it exists, use suffix to avoid collision.
-	branch := m.resolveBranch(fmt.Sprintf("%s/%s", m.BranchPrefix, slug))
+	entry.Branch = m.resolveBranch(fmt.Sprintf("%s/%s", m.BranchPrefix, slug))
 
-	cmd := exec.Command("git", "worktree", "add", "-b", branch, path, m.BaseBranch)
+	cmd := exec.Command("git", "worktree", "add", "-b", entry.Branch, path, base)
 	cmd.Dir = m.RepoRoot
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("git worktree add failed for %q: %w\n%s", slug, err, string(out))
 	}
 
-	entry := &Entry{
-		Slug:   slug,
-		Path:   path,
-		Branch: branch,
-		Status: "pending",
+	manifest[m.key(slug)] = *entry
+	return entry, nil
+}
+
+// heldByOtherRun reports whether an entry other than key uses path.
+func heldByOtherRun(manifest map[string]Entry, path, key string) bool {
+	for k, e := range manifest {
+		if k != key && e.Path == path {
+			return true
+		}
 	}
+	return false
+}
 
-	if err := m.saveEntry(entry); err != nil {
+// MergeBranches merges each branch, in order, into the worktree for slug so a
+// dependent task starts from its prerequisites' finished work. When the task has
+// no commits of its own yet, the entry's Base moves to the resulting commit, so
+// later diffs only cover the task's own changes. Otherwise (a resumed or retried
+// task whose prerequisite gained commits) Base stays put: diffs then include the
+// prerequisites' changes rather than dropping the task's earlier work. A
+// conflicting merge is aborted and reported as an error.
+func (m *Manager) MergeBranches(slug string, branches []string) (*Entry, error) {
+	entry, err := m.GetEntry(slug)
+	if err != nil {
 		return nil, err
 	}
 
+	var pending []string
+	for _, branch := range branches {
+		if !isAncestor(entry.Path, branch) {
+			pending = append(pending, branch)
+		}
+	}
+	// Already stacked (e.g. when resuming): keep the recorded Base.
+	if len(pending) == 0 {
+		return entry, nil
+	}
+
+	// The task's own commits are those on HEAD that are neither on its base
+	// nor on a prerequisite.
+	args := []string{"rev-list", "--count", "HEAD"}
+	if entry.Base != "" {
+		args = append(args, "^"+entry.Base)
+	}
+	for _, branch := range branches {
+		args = append(args, "^"+branch)
+	}
+	own, err := gitOutput(entry.Path, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count commits of %q: %w", slug, err)
+	}
+
+	for _, branch := range pending {
+		cmd := exec.Command("git", "merge", "--no-edit", branch)
+		cmd.Dir = entry.Path
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			abort := exec.Command("git", "merge", "--abort")
+			abort.Dir = entry.Path
+			_ = abort.Run()
+			return nil, fmt.Errorf("cannot merge %q into %q: %w\n%s", branch, entry.Branch, err, string(out))
+		}
+	}
+
+	if own != "0" {
+		return entry, nil
+	}
+	base, err := gitOutput(entry.Path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve HEAD for %q: %w", slug, err)
+	}
+
+	if err := m.Update(slug, func(e *Entry) { e.Base = base }); err != nil {
+		return nil, err
+	}
+	entry.Base = base
 	return entry, nil
 }
 
 // Prune runs `git worktree prune` to remove stale registrations and then
-// drops any manifest entries whose paths no longer exist on disk.
+// drops any manifest entries whose paths no longer exist on disk. When
+// RunID is set, only that run's entries are dropped.
 func (m *Manager) Prune() ([]string, error) {
 	cmd := exec.Command("git", "worktree", "prune")
 	cmd.Dir = m.RepoRoot
@@ -110,21 +289,21 @@ func (m *Manager) Prune() ([]string, error) {
 		return nil, fmt.Errorf("git worktree prune failed: %w\n%s", err, string(out))
 	}
 
-	manifest, err := m.loadManifest()
-	if err != nil {
-		return nil, err
-	}
-
 	var pruned []string
-	for slug, entry := range manifest {
-		if _, statErr := os.Stat(entry.Path); os.IsNotExist(statErr) {
-			if err := m.removeEntry(slug); err != nil {
-				return pruned, err
+	err = m.update(func(manifest map[string]Entry) error {
+		for key, entry := range manifest {
+			if m.RunID != "" && entry.RunID != m.RunID {
+				continue
+			}
+			if _, statErr := os.Stat(entry.Path); os.IsNotExist(statErr) {
+				delete(manifest, key)
+				pruned = append(pruned, entry.Slug)
 			}
-			pruned = append(pruned, slug)
 		}
-	}
-	return pruned, nil
+		return nil
+	})
+	sort.Strings(pruned)
+	return pruned, err
 }
 
 // Destroy removes the worktree and deletes its branch.
@@ -144,32 +323,79 @@ func (m *Manager) Destroy(slug string) error {
 	// Best-effort branch deletion — the branch may already be gone
 	exec.Command("git", "branch", "-D", entry.Branch).Run()
 
-	return m.removeEntry(slug)
+	return m.update(func(manifest map[string]Entry) error {
+		delete(manifest, m.key(slug))
+		return nil
+	})
 }
 
 // UpdateStatus sets the status field for a tracked worktree.
 func (m *Manager) UpdateStatus(slug, status string) error {
-	entry, err := m.GetEntry(slug)
+	return m.Update(slug, func(e *Entry) { e.Status = status })
+}
+
+// Update applies fn to the manifest entry for slug and saves the result.
+func (m *Manager) Update(slug string, fn func(*Entry)) error {
+	return m.update(func(manifest map[string]Entry) error {
+		e, ok := manifest[m.key(slug)]
+		if !ok {
+			return m.notFound(slug)
+		}
+		fn(&e)
+		manifest[m.key(slug)] = e
+		return nil
+	})
+}
+
+// Entries returns every manifest entry of every run, sorted by run, then slug.
+func (m *Manager) Entries() ([]*Entry, error) {
+	manifest, err := m.loadManifest()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(manifest))
+	for _, e := range manifest {
+		entry := e
+		entries = append(entries, &entry)
 	}
-	entry.Status = status
-	return m.saveEntry(entry)
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].RunID != entries[j].RunID {
+			return entries[i].RunID < entries[j].RunID
+		}
+		return entries[i].Slug < entries[j].Slug
+	})
+	return entries, nil
 }
 
-// GetEntry retrieves an entry from the manifest by slug.
+// GetEntry retrieves an entry of the manager's run from the manifest by slug.
 func (m *Manager) GetEntry(slug string) (*Entry, error) {
 	manifest, err := m.loadManifest()
 	if err != nil {
 		return nil, err
 	}
-	e, ok := manifest[slug]
+	e, ok := manifest[m.key(slug)]
 	if !ok {
-		return nil, fmt.Errorf("no worktree entry found for slug %q", slug)
+		return nil, m.notFound(slug)
 	}
 	return &e, nil
 }
 
+func (m *Manager) notFound(slug string) error {
+	if m.RunID != "" {
+		return fmt.Errorf("no worktree entry found for slug %q in run %s", slug, m.RunID)
+	}
+	return fmt.Errorf("no worktree entry found for slug %q", slug)
+}
+
+// key returns the manifest key of slug's entry in the manager's run. Entries
+// without a run ID are keyed by slug alone.
+func (m *Manager) key(slug string) string {
+	if m.RunID == "" {
+		return slug
+	}
+	return m.RunID + "/" + slug
+}
+
 // resolveBranch returns branchName if it doesn't exist yet, otherwise
 // appends -2, -3, ... until it finds an unused name.
 func (m *Manager) resolveBranch(branch string) string {
@@ -202,6 +428,26 @@ func refExists(repoRoot, ref string) bool {
 	return err == nil
 }
 
+// gitOutput runs git in dir and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// isAncestor reports whether ref is already contained in HEAD of the worktree at dir.
+func isAncestor(dir, ref string) bool {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", ref, "HEAD")
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
+
 func branchExists(repoRoot, branch string) bool {
 	cmd := exec.Command("git", "branch", "--list", branch)
 	cmd.Dir = repoRoot
@@ -283,66 +529,99 @@ func getWorktreeBranch(repoRoot, path string) string {
 	return ""
 }
 
-func (m *Manager) loadManifest() (map[string]Entry, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) manifestPath() string {
+	return filepath.Join(m.RepoRoot, manifestFile)
+}
 
-	manifest := make(map[string]Entry)
-	data, err := os.ReadFile(manifestFile)
-	if os.IsNotExist(err) {
-		return manifest, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(data, &manifest); err != nil {
-		return nil, err
+// lockPath returns the manifest lock file inside the git common dir, which
+// every worktree of the repo shares. Outside a git repo it falls back to a
+// file next to the manifest. Callers hold m.mu.
+func (m *Manager) lockPath() string {
+	if m.lock != "" {
+		return m.lock
+	}
+	m.lock = m.manifestPath() + ".lock"
+	if dir, err := gitOutput(m.RepoRoot, "rev-parse", "--git-common-dir"); err == nil {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(m.RepoRoot, dir)
+		}
+		m.lock = filepath.Join(dir, lockFileName)
 	}
-	return manifest, nil
+	return m.lock
 }
 
-func (m *Manager) saveEntry(entry *Entry) error {
+// withLock runs fn while holding the manifest lock, both against other
+// goroutines and, through an OS file lock, against other processes.
+func (m *Manager) withLock(fn func() error) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	manifest := make(map[string]Entry)
-	data, err := os.ReadFile(manifestFile)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	f, err := os.OpenFile(m.lockPath(), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open manifest lock: %w", err)
 	}
-	if len(data) > 0 {
-		if err := json.Unmarshal(data, &manifest); err != nil {
-			return err
-		}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("cannot lock manifest: %w", err)
 	}
+	defer unlockFile(f)
 
-	manifest[entry.Slug] = *entry
-	out, err := json.MarshalIndent(manifest, "", "  ")
-	if err != nil {
+	return fn()
+}
+
+func (m *Manager) loadManifest() (map[string]Entry, error) {
+	var manifest map[string]Entry
+	err := m.withLock(func() error {
+		var err error
+		manifest, err = m.readManifest()
 		return err
-	}
-	return os.WriteFile(manifestFile, out, 0644)
+	})
+	return manifest, err
 }
 
-func (m *Manager) removeEntry(slug string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// update applies fn to the manifest and writes the result back, all under
+// the manifest lock. Nothing is written when fn fails.
+func (m *Manager) update(fn func(manifest map[string]Entry) error) error {
+	return m.withLock(func() error {
+		manifest, err := m.readManifest()
+		if err != nil {
+			return err
+		}
+		if err := fn(manifest); err != nil {
+			return err
+		}
+		return m.writeManifest(manifest)
+	})
+}
 
+func (m *Manager) readManifest() (map[string]Entry, error) {
 	manifest := make(map[string]Entry)
-	data, err := os.ReadFile(manifestFile)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	data, err := os.ReadFile(m.manifestPath())
+	if os.IsNotExist(err) {
+		return manifest, nil
 	}
-	if len(data) > 0 {
-		if err := json.Unmarshal(data, &manifest); err != nil {
-			return err
-		}
+	if err != nil {
+		return nil, err
 	}
+	if len(data) == 0 {
+		return manifest, nil
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", manifestFile, err)
+	}
+	return manifest, nil
+}
 
-	delete(manifest, slug)
+// writeManifest replaces the manifest through a rename, so a crash never
+// leaves it half written.
+func (m *Manager) writeManifest(manifest map[string]Entry) error {
 	out, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(manifestFile, out, 0644)
+	tmp := m.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, out, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.manifestPath())
 }
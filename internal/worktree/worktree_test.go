@@ -4,7 +4,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/thisguymartin/ai-forge/internal/parser"
 )
 
 // setupTestRepo creates a temporary git repository with an initial commit.
@@ -21,7 +25,7 @@ func setupTestRepo(t *testing.T) string {
 		}
 	}
 
-	run("git", "init")
+	run("git", "init", "-b", "main")
 	run("git", "config", "user.email", "test@mochi.local")
 	run("git", "config", "user.name", "MOCHI Test")
 	run("git", "commit", "--allow-empty", "-m", "initial")
@@ -124,3 +128,290 @@ func TestCreate_Collision(t *testing.T) {
 		t.Errorf("Second Create failed: %v", err)
 	}
 }
+
+func TestMergeBranches_StacksPrerequisite(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	m := newTestManager(t, repoRoot)
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get cwd: %v", err)
+	}
+	if err := os.Chdir(repoRoot); err != nil {
+		t.Fatalf("cannot chdir to temp repo: %v", err)
+	}
+	defer os.Chdir(oldWd)
+
+	api, err := m.Create("add-api")
+	if err != nil {
+		t.Fatalf("Create(add-api) failed: %v", err)
+	}
+	client, err := m.Create("add-client")
+	if err != nil {
+		t.Fatalf("Create(add-client) failed: %v", err)
+	}
+	if want := revParse(t, repoRoot, "main"); client.Base != want {
+		t.Errorf("client.Base = %q; want main's commit %q", client.Base, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(api.Path, "api.go"), []byte("package api\n"), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+	for _, args := range [][]string{{"add", "api.go"}, {"commit", "-m", "add api"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = api.Path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	merged, err := m.MergeBranches("add-client", []string{api.Branch})
+	if err != nil {
+		t.Fatalf("MergeBranches failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(client.Path, "api.go")); err != nil {
+		t.Errorf("expected prerequisite file in dependent worktree: %v", err)
+	}
+
+	if want := revParse(t, repoRoot, api.Branch); merged.Base != want {
+		t.Errorf("merged.Base = %q; want %q", merged.Base, want)
+	}
+}
+
+func TestMergeBranches_KeepsBaseWithOwnWork(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	m := newTestManager(t, repoRoot)
+	commit := func(dir, name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", name}, {"commit", "-qm", "add " + name}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+	}
+
+	api, err := m.Create("add-api")
+	if err != nil {
+		t.Fatalf("Create(add-api) failed: %v", err)
+	}
+	client, err := m.Create("add-client")
+	if err != nil {
+		t.Fatalf("Create(add-client) failed: %v", err)
+	}
+	commit(api.Path, "api.go")
+	stacked, err := m.MergeBranches("add-client", []string{api.Branch})
+	if err != nil {
+		t.Fatalf("MergeBranches failed: %v", err)
+	}
+
+	// The dependent commits work of its own, then the prerequisite gains a
+	// commit (a resumed or retried run) and is merged again.
+	commit(client.Path, "client.go")
+	commit(api.Path, "api_test.go")
+	merged, err := m.MergeBranches("add-client", []string{api.Branch})
+	if err != nil {
+		t.Fatalf("second MergeBranches failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(client.Path, "api_test.go")); err != nil {
+		t.Errorf("expected the new prerequisite commit in the worktree: %v", err)
+	}
+	if merged.Base != stacked.Base {
+		t.Errorf("Base moved to %q past the task's own commit; want %q", merged.Base, stacked.Base)
+	}
+	if e, _ := m.GetEntry("add-client"); e.Base != stacked.Base {
+		t.Errorf("manifest Base = %q; want %q", e.Base, stacked.Base)
+	}
+}
+
+func revParse(t *testing.T, dir, ref string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", ref)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse %s failed: %v", ref, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestUpdate_PersistsResumeState(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	m := newTestManager(t, repoRoot)
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("cannot get cwd: %v", err)
+	}
+	if err := os.Chdir(repoRoot); err != nil {
+		t.Fatalf("cannot chdir to temp repo: %v", err)
+	}
+	defer os.Chdir(oldWd)
+
+	if _, err := m.Create("b-task"); err != nil {
+		t.Fatalf("Create(b-task) failed: %v", err)
+	}
+	if _, err := m.Create("a-task"); err != nil {
+		t.Fatalf("Create(a-task) failed: %v", err)
+	}
+
+	task := parser.Task{Title: "A task", Slug: "a-task", DependsOn: []string{"b-task"}}
+	if err := m.Update("a-task", func(e *Entry) {
+		e.Task = &task
+		e.Iteration = 2
+		e.Status = "running"
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	entries, err := m.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Slug != "a-task" || entries[1].Slug != "b-task" {
+		t.Fatalf("Entries = %v; want a-task, b-task", entries)
+	}
+	got := entries[0]
+	if got.Task == nil || got.Task.Title != "A task" || len(got.Task.DependsOn) != 1 {
+		t.Errorf("Task = %+v; want recorded task", got.Task)
+	}
+	if got.Iteration != 2 || got.Status != "running" {
+		t.Errorf("Iteration, Status = %d, %q; want 2, running", got.Iteration, got.Status)
+	}
+}
+
+func TestCreate_NamespacesRuns(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	first, second := newTestManager(t, repoRoot), newTestManager(t, repoRoot)
+	first.RunID, second.RunID = "20260101-120000-aaaa", "20260101-130000-bbbb"
+
+	a, err := first.Create("add-auth")
+	if err != nil {
+		t.Fatalf("first Create failed: %v", err)
+	}
+	b, err := second.Create("add-auth")
+	if err != nil {
+		t.Fatalf("second Create failed: %v", err)
+	}
+	if a.Path == b.Path || a.Branch == b.Branch {
+		t.Errorf("runs share a worktree: %s (%s) and %s (%s)", a.Path, a.Branch, b.Path, b.Branch)
+	}
+
+	entries, err := first.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 || LatestRun(entries) != second.RunID {
+		t.Fatalf("Entries = %d, latest run %q; want 2, %q", len(entries), LatestRun(entries), second.RunID)
+	}
+
+	if err := first.UpdateStatus("add-auth", "done"); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	if e, _ := second.GetEntry("add-auth"); e.Status != "pending" {
+		t.Errorf("second run's entry status = %q; want it untouched", e.Status)
+	}
+
+	if err := first.Destroy("add-auth"); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if _, err := os.Stat(b.Path); err != nil {
+		t.Errorf("second run's worktree removed: %v", err)
+	}
+	if _, err := first.GetEntry("add-auth"); err == nil {
+		t.Error("first run's entry still in the manifest")
+	}
+	if got := RunEntries(mustEntries(t, second), second.RunID); len(got) != 1 {
+		t.Errorf("second run has %d entries; want 1", len(got))
+	}
+}
+
+func TestPrune_ScopedToRun(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	first, second := newTestManager(t, repoRoot), newTestManager(t, repoRoot)
+	first.RunID, second.RunID = "run-a", "run-b"
+
+	for _, m := range []*Manager{first, second} {
+		e, err := m.Create("task")
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		os.RemoveAll(e.Path)
+	}
+
+	pruned, err := first.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(pruned) != 1 {
+		t.Errorf("pruned %v; want only run-a's entry", pruned)
+	}
+	if _, err := second.GetEntry("task"); err != nil {
+		t.Errorf("run-b's entry was pruned: %v", err)
+	}
+}
+
+func TestUpdate_ConcurrentManagers(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	seed := newTestManager(t, repoRoot)
+	slugs := []string{"a", "b", "c", "d"}
+	for _, slug := range slugs {
+		if _, err := seed.Create(slug); err != nil {
+			t.Fatalf("Create(%s) failed: %v", slug, err)
+		}
+	}
+
+	// Separate managers share no mutex, like separate mochi processes; only
+	// the file lock keeps their read-modify-writes from losing updates.
+	var wg sync.WaitGroup
+	for _, slug := range slugs {
+		wg.Add(1)
+		go func(slug string) {
+			defer wg.Done()
+			m := newTestManager(t, repoRoot)
+			for i := 1; i <= 20; i++ {
+				if err := m.Update(slug, func(e *Entry) { e.Iteration = i }); err != nil {
+					t.Errorf("Update(%s) failed: %v", slug, err)
+					return
+				}
+			}
+		}(slug)
+	}
+	wg.Wait()
+
+	for _, e := range mustEntries(t, seed) {
+		if e.Iteration != 20 {
+			t.Errorf("%s: Iteration = %d; want 20 (lost update)", e.Slug, e.Iteration)
+		}
+	}
+}
+
+func TestUpdate_LockOutsideWorkTree(t *testing.T) {
+	repoRoot := setupTestRepo(t)
+	m := newTestManager(t, repoRoot)
+	if _, err := m.Create("a"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(repoRoot, ".git", lockFileName)); err != nil {
+		t.Errorf("lock not in the git dir: %v", err)
+	}
+	for _, name := range []string{manifestFile + ".lock", manifestFile + ".tmp"} {
+		if _, err := os.Stat(filepath.Join(repoRoot, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left in the repo root", name)
+		}
+	}
+}
+
+func mustEntries(t *testing.T, m *Manager) []*Entry {
+	t.Helper()
+	entries, err := m.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	return entries
+}
@@ -0,0 +1,140 @@
+// Package mochi runs MOCHI from Go programs. It is the API behind the mochi
+// command: a Runner takes a Config, runs every task of its task file in its own
+// worktree through the Ralph Loop, dispatches the output, and returns the
+// record of the run instead of exiting the process.
+//
+//	cfg, err := mochi.LoadConfig(repo)
+//	if err != nil { ... }
+//	cfg.InputFile = "PRD.md"
+//	res, err := mochi.NewRunner(mochi.Options{
+//		Config:   cfg,
+//		RepoRoot: repo,
+//		Output:   io.Discard,
+//		OnEvent:  func(e mochi.Event) { log.Println(e.Type, e.Task, e.Status) },
+//	}).Run(ctx)
+package mochi
+
+import (
+	"context"
+	"io"
+
+	"github.com/thisguymartin/ai-forge/internal/config"
+	"github.com/thisguymartin/ai-forge/internal/event"
+	"github.com/thisguymartin/ai-forge/internal/orchestrator"
+	"github.com/thisguymartin/ai-forge/internal/report"
+	"github.com/thisguymartin/ai-forge/internal/verify"
+)
+
+// Config is the full set of run settings; see config/config.example.yaml.
+type Config = config.Config
+
+// ProviderConfig declares an agent provider as a command template.
+type ProviderConfig = config.ProviderConfig
+
+// RunResult is the record of a run: timing, outcome counts and every task.
+type RunResult = report.Run
+
+// TaskResult is the record of one task of a run.
+type TaskResult = report.Task
+
+// Review is the last reviewer verdict of a task.
+type Review = report.Review
+
+// RetryOptions select the task Runner.Retry runs again and what changes for
+// the new attempt.
+type RetryOptions = orchestrator.RetryOptions
+
+// VerificationResult is the outcome of one verification command.
+type VerificationResult = verify.Result
+
+// Event is one lifecycle event of a run.
+type Event = event.Event
+
+// EventType identifies what an Event reports.
+type EventType = event.Type
+
+// Event types, in the order a task goes through them.
+const (
+	EventRunStarted        = event.RunStarted
+	EventWorktreeCreated   = event.WorktreeCreated
+	EventTaskQueued        = event.TaskQueued
+	EventTaskStarted       = event.TaskStarted
+	EventIterationStarted  = event.IterationStarted
+	EventVerificationDone  = event.VerificationDone
+	EventReviewVerdict     = event.ReviewVerdict
+	EventIterationFinished = event.IterationFinished
+	EventTaskFinished      = event.TaskFinished
+	EventOutputWritten     = event.OutputWritten
+	EventPRCreated         = event.PRCreated
+	EventWorktreeRemoved   = event.WorktreeRemoved
+	EventRunFinished       = event.RunFinished
+)
+
+// DefaultConfig returns the built-in defaults.
+func DefaultConfig() Config {
+	return config.Default()
+}
+
+// LoadConfig returns the config the mochi command would use in repoRoot:
+// defaults, then the user and repo config files, then MOCHI_* variables.
+func LoadConfig(repoRoot string) (Config, error) {
+	return config.Load(repoRoot)
+}
+
+// Options configure a Runner.
+type Options struct {
+	Config Config
+
+	// RepoRoot is the repository to run in; relative paths in Config are
+	// taken relative to it. Empty means the current directory.
+	RepoRoot string
+
+	// Output receives the progress lines the mochi command prints. Nil means
+	// os.Stdout; use io.Discard to silence them. Each run has its own output;
+	// runs that share a writer need one that is safe for concurrent use.
+	Output io.Writer
+
+	// OnEvent, if set, is called for every lifecycle event, one at a time and
+	// in order. It runs on the goroutine that published the event, so it
+	// should return quickly. Config.EventLog and Config.Hooks receive the same
+	// events.
+	OnEvent func(Event)
+}
+
+// Runner runs MOCHI with fixed options.
+type Runner struct {
+	opts Options
+}
+
+// NewRunner returns a Runner for opts.
+func NewRunner(opts Options) *Runner {
+	return &Runner{opts: opts}
+}
+
+// Run parses the task file and runs every task. Tasks that fail are reported
+// in the result rather than as an error; the error is set when the run could
+// not start or was cancelled through ctx.
+func (r *Runner) Run(ctx context.Context) (RunResult, error) {
+	return orchestrator.Run(ctx, r.opts.Config, r.orchestratorOptions())
+}
+
+// Resume continues an interrupted run from the worktree manifest, like
+// 'mochi resume'.
+func (r *Runner) Resume(ctx context.Context) (RunResult, error) {
+	return orchestrator.Resume(ctx, r.opts.Config, r.orchestratorOptions())
+}
+
+// Retry runs one task of an earlier run again in its existing worktree, like
+// 'mochi retry'. Config.RunID selects the run; empty means the most recent
+// run that has the task.
+func (r *Runner) Retry(ctx context.Context, retry RetryOptions) (RunResult, error) {
+	return orchestrator.Retry(ctx, r.opts.Config, r.orchestratorOptions(), retry)
+}
+
+func (r *Runner) orchestratorOptions() orchestrator.Options {
+	return orchestrator.Options{
+		RepoRoot: r.opts.RepoRoot,
+		Out:      r.opts.Output,
+		OnEvent:  r.opts.OnEvent,
+	}
+}
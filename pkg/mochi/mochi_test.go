@@ -0,0 +1,203 @@
+package mochi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setupRepo creates a repository with a task file and a fake provider whose
+// agent commits a change, or fails for tasks mentioning "broken" unless told
+// to "try harder".
+func setupRepo(t *testing.T) (string, Config) {
+	t.Helper()
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("test\n"), 0644)
+	os.WriteFile(filepath.Join(repo, ".gitignore"), []byte(".worktrees/\nlogs/\n.mochi/\n.mochi_manifest.json\n"), 0644)
+	run("add", ".")
+	run("commit", "-qm", "init")
+
+	os.WriteFile(filepath.Join(repo, "PRD.md"), []byte("## Tasks\n- Add a file\n- Fix the broken thing\n"), 0644)
+	agent := filepath.Join(repo, "fake-agent.sh")
+	os.WriteFile(agent, []byte("#!/bin/sh\ngrep -q broken \"$1\" && ! grep -q 'try harder' \"$1\" && exit 1\necho done >> out.txt && git add out.txt && git commit -qm work\n"), 0755)
+
+	cfg := DefaultConfig()
+	cfg.InputFile = "PRD.md"
+	cfg.Model = "fake-1"
+	cfg.BaseBranch = "main"
+	cfg.Timeout = 30
+	cfg.Learnings = false
+	cfg.Providers = []ProviderConfig{{Name: "fake", Match: "^fake-", Command: agent + " {{.PromptFile}}"}}
+	return repo, cfg
+}
+
+func TestRunner_Run(t *testing.T) {
+	repo, cfg := setupRepo(t)
+
+	var mu sync.Mutex
+	var events []Event
+	var progress bytes.Buffer
+	res, err := NewRunner(Options{
+		Config:   cfg,
+		RepoRoot: repo,
+		Output:   &progress,
+		OnEvent: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if res.Succeeded != 1 || res.Failed != 1 || len(res.Tasks) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	for _, task := range res.Tasks {
+		wantStatus := "done"
+		if strings.Contains(task.Title, "broken") {
+			wantStatus = "failed"
+		}
+		if task.Status != wantStatus {
+			t.Errorf("task %s status = %q, want %q", task.Slug, task.Status, wantStatus)
+		}
+	}
+	if !strings.Contains(progress.String(), "Run complete: 1 succeeded, 1 failed") {
+		t.Errorf("progress output missing the summary:\n%s", progress.String())
+	}
+
+	count := make(map[EventType]int)
+	for _, e := range events {
+		count[e.Type]++
+	}
+	if count[EventRunStarted] != 1 || count[EventTaskStarted] != 2 || count[EventTaskFinished] != 2 || count[EventRunFinished] != 1 {
+		t.Errorf("events = %v", count)
+	}
+	if last := events[len(events)-1]; last.Type != EventRunFinished {
+		t.Errorf("last event = %+v, want run_finished", last)
+	}
+}
+
+func TestRunner_ConcurrentOutput(t *testing.T) {
+	var wg sync.WaitGroup
+	progress := make([]bytes.Buffer, 2)
+	for i := range progress {
+		repo, cfg := setupRepo(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := NewRunner(Options{Config: cfg, RepoRoot: repo, Output: &progress[i]}).Run(context.Background()); err != nil {
+				t.Errorf("Run %d failed: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each Runner writes to its own Output only.
+	for i := range progress {
+		if n := strings.Count(progress[i].String(), "Run complete"); n != 1 {
+			t.Errorf("output %d has %d summaries:\n%s", i, n, progress[i].String())
+		}
+	}
+}
+
+func TestRunner_Retry(t *testing.T) {
+	repo, cfg := setupRepo(t)
+	cfg.KeepFailed = true
+	runner := NewRunner(Options{Config: cfg, RepoRoot: repo, Output: io.Discard})
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The failed task's worktree is kept; the successful one is removed.
+	const slug = "fix-the-broken-thing"
+	if _, err := os.Stat(filepath.Join(repo, ".worktrees", slug)); err != nil {
+		t.Fatalf("failed worktree was not kept: %v", err)
+	}
+	if _, err := runner.Retry(context.Background(), RetryOptions{Slug: "add-a-file"}); err == nil {
+		t.Error("Retry of a removed worktree succeeded")
+	}
+
+	res, err := runner.Retry(context.Background(), RetryOptions{Slug: slug, Instructions: "try harder"})
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if res.Succeeded != 1 || len(res.Tasks) != 1 || res.Tasks[0].Slug != slug {
+		t.Fatalf("result = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".worktrees", slug)); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after a successful retry: %v", err)
+	}
+}
+
+func TestRunner_RetryRestartsLoop(t *testing.T) {
+	repo, cfg := setupRepo(t)
+	cfg.KeepFailed = true
+	cfg.MaxIterations = 3
+	// Each pass appends a line; verification passes from the fourth on, so
+	// the first run fails at its last iteration.
+	cfg.Verify = []string{"test $(grep -c done out.txt) -ge 4"}
+	runner := NewRunner(Options{Config: cfg, RepoRoot: repo, Output: io.Discard})
+	res, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(res.Tasks) == 0 || res.Tasks[0].Slug != "add-a-file" || res.Tasks[0].Status != "failed" || res.Tasks[0].Iterations != 3 {
+		t.Fatalf("first run = %+v; want add-a-file failed at iteration 3", res.Tasks)
+	}
+
+	res, err = runner.Retry(context.Background(), RetryOptions{Slug: "add-a-file"})
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if res.Succeeded != 1 || res.Tasks[0].Iterations != 1 {
+		t.Errorf("retry = %+v; want it done at iteration 1 of a fresh loop", res.Tasks)
+	}
+}
+
+func TestRunner_RetrySkipped(t *testing.T) {
+	repo, cfg := setupRepo(t)
+	os.WriteFile(filepath.Join(repo, "PRD.md"), []byte("## Tasks\n- Fix the broken thing\n- Use the fix [after:fix-the-broken-thing]\n"), 0644)
+	cfg.KeepFailed = true
+	runner := NewRunner(Options{Config: cfg, RepoRoot: repo, Output: io.Discard})
+	res, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if res.Failed != 2 {
+		t.Fatalf("result = %+v; want the prerequisite failed and its dependent skipped", res)
+	}
+
+	if _, err := runner.Retry(context.Background(), RetryOptions{Slug: "use-the-fix"}); err == nil || !strings.Contains(err.Error(), "retry it first") {
+		t.Fatalf("Retry of the dependent before its prerequisite = %v; want a 'retry it first' error", err)
+	}
+	if _, err := runner.Retry(context.Background(), RetryOptions{Slug: "fix-the-broken-thing", Instructions: "try harder"}); err != nil {
+		t.Fatalf("Retry of the prerequisite failed: %v", err)
+	}
+	// Kept for the dependent's retry, which merges its branch.
+	if _, err := os.Stat(filepath.Join(repo, ".worktrees", "fix-the-broken-thing")); err != nil {
+		t.Fatalf("prerequisite worktree was removed while a skipped task needs it: %v", err)
+	}
+	res, err = runner.Retry(context.Background(), RetryOptions{Slug: "use-the-fix"})
+	if err != nil || res.Succeeded != 1 {
+		t.Fatalf("Retry of the dependent = %+v, %v", res, err)
+	}
+}